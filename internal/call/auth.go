@@ -0,0 +1,265 @@
+package call
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported Auth types
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+	AuthOAuth2 = "oauth2"
+	AuthHMAC   = "hmac"
+	AuthSigV4  = "sigv4"
+)
+
+// Auth describes how every request of a Config authenticates itself,
+// so credentials survive token expiration during long runs
+type Auth struct {
+	Type         string   `json:"type"`
+	Username     string   `json:"username,omitempty"`      // basic
+	Password     string   `json:"password,omitempty"`      // basic
+	Token        string   `json:"token,omitempty"`         // bearer
+	TokenURL     string   `json:"token_url,omitempty"`     // oauth2 client credentials endpoint
+	ClientID     string   `json:"client_id,omitempty"`     // oauth2
+	ClientSecret string   `json:"client_secret,omitempty"` // oauth2
+	Scopes       []string `json:"scopes,omitempty"`        // oauth2
+	KeyID        string   `json:"key_id,omitempty"`        // hmac key id or sigv4 access key
+	Secret       string   `json:"secret,omitempty"`        // hmac or sigv4 secret key
+	Header       string   `json:"header,omitempty"`        // hmac signature header, Authorization by default
+	Region       string   `json:"region,omitempty"`        // sigv4
+	Service      string   `json:"service,omitempty"`       // sigv4
+	SessionToken string   `json:"session_token,omitempty"` // sigv4
+}
+
+// authTransport signs every request before handing it to the base
+// transport. A nil base means http.DefaultTransport
+type authTransport struct {
+	auth Auth
+	base http.RoundTripper
+
+	mu      sync.Mutex
+	token   string    // oauth2 access token
+	expires time.Time // oauth2 access token expiration
+}
+
+// check validates the fields required by each Auth type
+func (a *Auth) check() error {
+	var missing []string
+	require := func(name, value string) {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	switch a.Type {
+	case AuthBasic:
+		require("username", a.Username)
+	case AuthBearer:
+		require("token", a.Token)
+	case AuthOAuth2:
+		require("token_url", a.TokenURL)
+		require("client_id", a.ClientID)
+		require("client_secret", a.ClientSecret)
+	case AuthHMAC:
+		require("key_id", a.KeyID)
+		require("secret", a.Secret)
+	case AuthSigV4:
+		require("key_id", a.KeyID)
+		require("secret", a.Secret)
+		require("region", a.Region)
+		require("service", a.Service)
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidAuth, a.Type)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s requires %s", ErrInvalidAuth, a.Type, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (t *authTransport) transport() http.RoundTripper {
+	if t.base != nil {
+		return t.base
+	}
+	return http.DefaultTransport
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	switch t.auth.Type {
+	case AuthBasic:
+		req.SetBasicAuth(t.auth.Username, t.auth.Password)
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+t.auth.Token)
+	case AuthOAuth2:
+		token, err := t.accessToken()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case AuthHMAC, AuthSigV4:
+		body, err := requestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		if t.auth.Type == AuthHMAC {
+			signHMAC(req, t.auth, body, time.Now())
+		} else {
+			signV4(req, t.auth, body, time.Now())
+		}
+	}
+	return t.transport().RoundTrip(req)
+}
+
+// accessToken returns a cached OAuth2 token, fetching a new one with
+// the client credentials grant when it is about to expire
+func (t *authTransport) accessToken() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && time.Now().Before(t.expires) {
+		return t.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(t.auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(t.auth.Scopes, " "))
+	}
+	req, err := http.NewRequest(http.MethodPost, t.auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(t.auth.ClientID), url.QueryEscape(t.auth.ClientSecret))
+	resp, err := t.transport().RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: token endpoint answered %d", ErrAuthFailed, resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("%w: token endpoint returned no access_token", ErrAuthFailed)
+	}
+	lifetime := time.Duration(token.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = time.Hour
+	}
+	// refresh ahead of time so in-flight requests never carry a stale token
+	t.token, t.expires = token.AccessToken, time.Now().Add(lifetime*9/10)
+	return t.token, nil
+}
+
+// requestBody reads the body of a request without consuming it
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body = io.NopCloser(strings.NewReader(string(body)))
+		return body, err
+	}
+	reader, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// signHMAC signs method, path, date and body digest with HMAC-SHA256
+func signHMAC(req *http.Request, auth Auth, body []byte, now time.Time) {
+	date := now.UTC().Format(http.TimeFormat)
+	digest := sha256.Sum256(body)
+	stringToSign := strings.Join([]string{req.Method, req.URL.RequestURI(), date, hex.EncodeToString(digest[:])}, "\n")
+	mac := hmac.New(sha256.New, []byte(auth.Secret))
+	mac.Write([]byte(stringToSign))
+
+	header := auth.Header
+	if header == "" {
+		header = "Authorization"
+	}
+	req.Header.Set("Date", date)
+	req.Header.Set(header, fmt.Sprintf("HMAC-SHA256 KeyId=%s,Signature=%s",
+		auth.KeyID, base64.StdEncoding.EncodeToString(mac.Sum(nil))))
+}
+
+// signV4 signs a request following AWS Signature Version 4
+func signV4(req *http.Request, auth Auth, body []byte, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	day := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if auth.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", auth.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	if req.Host != "" {
+		headers["host"] = req.Host
+	}
+	for key, values := range req.Header {
+		key = strings.ToLower(key)
+		if strings.HasPrefix(key, "x-amz-") || key == "content-type" {
+			headers[key] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payload := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payload[:]),
+	}, "\n")
+
+	scope := strings.Join([]string{day, auth.Region, auth.Service, "aws4_request"}, "/")
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(hashedRequest[:])}, "\n")
+
+	key := []byte("AWS4" + auth.Secret)
+	for _, part := range []string{day, auth.Region, auth.Service, "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		auth.KeyID, scope, signedHeaders, hex.EncodeToString(key)))
+}
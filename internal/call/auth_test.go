@@ -0,0 +1,149 @@
+package call
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTokenServer stubs an OAuth2 client credentials endpoint issuing
+// numbered tokens, counting how many were issued
+func newTokenServer(issued *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if r.FormValue("grant_type") != "client_credentials" || id != "client" || secret != "s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := atomic.AddInt32(issued, 1)
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "bearer", "expires_in": 3600}`, n)
+	}))
+}
+
+func TestMakeCallsWithOAuth2ClientCredentials(test *testing.T) {
+	var issued int32
+	tokens := newTokenServer(&issued)
+	defer tokens.Close()
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer target.Close()
+
+	config := Config{
+		Name:   "oauth2",
+		Method: http.MethodGet,
+		URL:    target.URL,
+		Auth:   &Auth{Type: AuthOAuth2, TokenURL: tokens.URL, ClientID: "client", ClientSecret: "s3cr3t"},
+	}
+	assert.Nil(test, config.CheckDefaults())
+	call := ConcurrentCall{Attempts: 20, ConcurrentAttempts: 5}
+	call.URL, _ = url.Parse(target.URL)
+	call.SetConfig(config)
+	result := call.MakeIt()
+
+	assert.Equal(test, 20, result.status[200].total)
+	assert.Equal(test, int32(1), atomic.LoadInt32(&issued))
+}
+
+func TestOAuth2TokenIsRefreshedWhenExpired(test *testing.T) {
+	var issued int32
+	tokens := newTokenServer(&issued)
+	defer tokens.Close()
+
+	transport := &authTransport{auth: Auth{Type: AuthOAuth2, TokenURL: tokens.URL, ClientID: "client", ClientSecret: "s3cr3t"}}
+	token, err := transport.accessToken()
+	assert.Nil(test, err)
+	assert.Equal(test, "token-1", token)
+
+	token, _ = transport.accessToken()
+	assert.Equal(test, "token-1", token)
+
+	transport.expires = time.Now().Add(-time.Second)
+	token, _ = transport.accessToken()
+	assert.Equal(test, "token-2", token)
+}
+
+func TestOAuth2WithWrongCredentials(test *testing.T) {
+	var issued int32
+	tokens := newTokenServer(&issued)
+	defer tokens.Close()
+
+	transport := &authTransport{auth: Auth{Type: AuthOAuth2, TokenURL: tokens.URL, ClientID: "client", ClientSecret: "wrong"}}
+	_, err := transport.accessToken()
+	assert.NotNil(test, err)
+}
+
+func TestAuthTransportSetsCredentials(test *testing.T) {
+	var got *http.Request
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer target.Close()
+
+	tests := []struct {
+		name string
+		auth Auth
+		want string
+	}{
+		{"basic", Auth{Type: AuthBasic, Username: "alice", Password: "pw"}, "Basic YWxpY2U6cHc="},
+		{"bearer", Auth{Type: AuthBearer, Token: "abc"}, "Bearer abc"},
+	}
+	for _, tt := range tests {
+		test.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: &authTransport{auth: tt.auth}}
+			req, _ := http.NewRequest(http.MethodGet, target.URL, nil)
+			resp, err := client.Do(req)
+			assert.Nil(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.want, got.Header.Get("Authorization"))
+			assert.Equal(t, "", req.Header.Get("Authorization"))
+		})
+	}
+}
+
+func TestSignHMAC(test *testing.T) {
+	auth := Auth{Type: AuthHMAC, KeyID: "key", Secret: "s3cr3t", Header: "X-Signature"}
+	req, _ := http.NewRequest(http.MethodPost, "http://www.foo.com/cart?id=1", strings.NewReader(`{}`))
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	signHMAC(req, auth, []byte(`{}`), now)
+
+	digest := sha256.Sum256([]byte(`{}`))
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	fmt.Fprintf(mac, "POST\n/cart?id=1\nThu, 02 Jan 2020 03:04:05 GMT\n%x", digest)
+	want := "HMAC-SHA256 KeyId=key,Signature=" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(test, "Thu, 02 Jan 2020 03:04:05 GMT", req.Header.Get("Date"))
+	assert.Equal(test, want, req.Header.Get("X-Signature"))
+}
+
+// TestSignV4 uses the get-vanilla case of the AWS Signature Version 4 test suite
+func TestSignV4(test *testing.T) {
+	auth := Auth{Type: AuthSigV4, KeyID: "AKIDEXAMPLE", Secret: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", Region: "us-east-1", Service: "service"}
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	signV4(req, auth, nil, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(test, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(test, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestAuthCheck(test *testing.T) {
+	assert.NotNil(test, (&Auth{Type: "digest"}).check())
+	assert.NotNil(test, (&Auth{Type: AuthBearer}).check())
+	assert.NotNil(test, (&Auth{Type: AuthOAuth2, TokenURL: "http://www.foo.com/token"}).check())
+	assert.NotNil(test, (&Auth{Type: AuthSigV4, KeyID: "key", Secret: "s3cr3t"}).check())
+	assert.Nil(test, (&Auth{Type: AuthHMAC, KeyID: "key", Secret: "s3cr3t"}).check())
+}
@@ -1,7 +1,7 @@
 package call
 
 import (
-	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -27,17 +27,26 @@ type ConcurrentCall struct {
 	config             Config   // configs from file
 	Attempts           int      // number of Attempts
 	ConcurrentAttempts int      // number of concurrent Attempts
+
+	limiter chan struct{} // slots of the requests in flight shared by a suite, unlimited when nil
+	quiet   bool          // no progress spinner, for calls running along others
 }
 
 // A Result contains the info to be outputted at the end
 // of the operation
 type Result struct {
 	URL            *url.URL                    // Endpoint tested
+	name           string                      // scenario step name
 	status         map[int]StatusCodeBenchmark // status codes
 	totalExecution float64                     // total execution time
 	avgExecution   float64                     // average execution time
 	minExecution   float64                     // min execution time
 	maxExecution   float64                     // min execution time
+	checks         []CheckBenchmark            // response checks
+	steps          []Result                    // per step results of a scenario
+	endpoints      []Result                    // per endpoint results of a traffic mix
+	failedFlows    int                         // scenario flows stopped before their last step
+	environment    string                      // config environment the call ran against
 }
 
 // HTTPResponse status code and execution time
@@ -45,56 +54,106 @@ type HTTPResponse struct {
 	status    int     // status codes
 	err       error   // possible error
 	execution float64 // total execution time
+	checks    []bool  // whether each configured check passed
 }
 
 // StatusCodeBenchmark with total of occurrences, execution time
 type StatusCodeBenchmark struct {
-	total     int     // total
-	execution float64 // total execution time
+	total      int       // total
+	execution  float64   // total execution time
+	executions []float64 // execution time of every response
 }
 
 // MakeIt executes a call and return its results
 func (call *ConcurrentCall) MakeIt() (result Result) {
 	result = Result{
 		URL:            call.URL,
+		name:           call.config.Name,
 		status:         make(map[int]StatusCodeBenchmark),
+		checks:         newCheckBenchmarks(call.config.Checks),
+		environment:    call.config.environment,
 		totalExecution: 0,
 		avgExecution:   0,
 		minExecution:   0,
 		maxExecution:   0}
 
 	beginning := time.Now()
-	if call.config.Name != "" {
-		fmt.Println("Case: ", call.config.Name)
+	// calls running along others leave their header to the printing
+	// of their results, so it cannot interleave
+	if !call.quiet {
+		printCase(result)
 	}
 	s := spinner.New(spinner.CharSets[31], 300*time.Millisecond)
 	s.Prefix = "😎 "
-	s.Suffix = " " + call.URL.String()
-	s.Start()
-	totalAttempts := call.Attempts
+	s.Suffix = " " + MaskSecrets(call.URL.String())
+	if !call.quiet {
+		s.Start()
+	}
+	clients := newClients(call.config, calcConcurrentAttempts(*call), call.limiter)
+	if len(call.config.Steps) > 0 {
+		call.makeScenario(&result, clients)
+	} else if len(call.config.Mix) > 0 {
+		call.makeMix(&result, clients)
+	}
 	for call.Attempts > 0 {
 		concurrentAttempts := calcConcurrentAttempts(*call)
-		responses := callURL(call.URL, concurrentAttempts, call.config)
+		responses := callURL(call.URL, concurrentAttempts, call.config, clients)
 		for _, response := range responses {
-			statusCodeBenchmark := result.status[response.status]
-			statusCodeBenchmark.total++
-			statusCodeBenchmark.execution += response.execution
-			result.status[response.status] = statusCodeBenchmark
-			if result.minExecution == 0 || result.minExecution > response.execution {
-				result.minExecution = response.execution
-			}
-			if result.maxExecution == 0 || result.maxExecution < response.execution {
-				result.maxExecution = response.execution
-			}
+			result.record(response.status, response.execution)
+			result.recordChecks(response.checks)
 		}
 		call.Attempts -= concurrentAttempts
 	}
-	s.Stop()
+	if !call.quiet {
+		s.Stop()
+	}
+	// the average comes from the responses, leaving think time and
+	// pacing out, while the elapsed time covers the whole run
+	result.summarize()
 	result.totalExecution = time.Since(beginning).Seconds()
-	result.avgExecution = result.totalExecution / float64(totalAttempts)
 	return
 }
 
+// record accounts a single response into the result
+func (r *Result) record(status int, execution float64) {
+	statusCodeBenchmark := r.status[status]
+	statusCodeBenchmark.total++
+	statusCodeBenchmark.execution += execution
+	statusCodeBenchmark.executions = append(statusCodeBenchmark.executions, execution)
+	r.status[status] = statusCodeBenchmark
+	if r.minExecution == 0 || r.minExecution > execution {
+		r.minExecution = execution
+	}
+	if r.maxExecution == 0 || r.maxExecution < execution {
+		r.maxExecution = execution
+	}
+}
+
+// recordChecks accounts the check outcomes of a single response
+func (r *Result) recordChecks(passed []bool) {
+	for i, ok := range passed {
+		if ok {
+			r.checks[i].passed++
+		} else {
+			r.checks[i].failed++
+		}
+	}
+}
+
+// summarize computes total and average execution times of a result
+// built from individual responses, such as a scenario step
+func (r *Result) summarize() {
+	total := 0
+	r.totalExecution = 0
+	for _, benchmark := range r.status {
+		total += benchmark.total
+		r.totalExecution += benchmark.execution
+	}
+	if total > 0 {
+		r.avgExecution = r.totalExecution / float64(total)
+	}
+}
+
 // GetStatus returns the status codes map for external access
 func (r *Result) GetStatus() map[int]StatusCodeBenchmark {
 	return r.status
@@ -120,6 +179,36 @@ func (r *Result) GetMaxExecution() float64 {
 	return r.maxExecution
 }
 
+// GetChecks returns the pass/fail benchmark of every response check
+func (r *Result) GetChecks() []CheckBenchmark {
+	return r.checks
+}
+
+// GetName returns the scenario step name
+func (r *Result) GetName() string {
+	return r.name
+}
+
+// GetSteps returns the per step results of a scenario
+func (r *Result) GetSteps() []Result {
+	return r.steps
+}
+
+// GetEndpoints returns the per endpoint results of a traffic mix
+func (r *Result) GetEndpoints() []Result {
+	return r.endpoints
+}
+
+// GetFailedFlows returns how many scenario flows stopped before their last step
+func (r *Result) GetFailedFlows() int {
+	return r.failedFlows
+}
+
+// GetEnvironment returns the config environment the call ran against
+func (r *Result) GetEnvironment() string {
+	return r.environment
+}
+
 // GetTotal returns the total count for a status code benchmark
 func (s *StatusCodeBenchmark) GetTotal() int {
 	return s.total
@@ -130,11 +219,18 @@ func (s *StatusCodeBenchmark) GetExecution() float64 {
 	return s.execution
 }
 
-// SetConfig sets the configuration for the ConcurrentCall
+// SetConfig sets the configuration for the ConcurrentCall, whose auth
+// credentials are masked from then on
 func (c *ConcurrentCall) SetConfig(config Config) {
+	registerAuthSecrets(config.Auth)
 	c.config = config
 }
 
+// GetConfig returns the configuration of the ConcurrentCall
+func (c *ConcurrentCall) GetConfig() Config {
+	return c.config
+}
+
 // It calculates the amount of concurrent calls to be executed,
 // based on the attempts left. It ensures that the next round
 // of concurrent calls will respect the attempts left of a given call
@@ -146,20 +242,64 @@ func calcConcurrentAttempts(call ConcurrentCall) (numberOfConcurrentAttempts int
 	return
 }
 
-// This func calls an URL concurrently
-func callURL(callerURL *url.URL, concurrentAttempts int, config Config) (responses []HTTPResponse) {
+// newClients returns the http.Client used by each of the n workers of
+// a call. Without cookie jars, auth nor connection settings, all
+// workers share http.DefaultClient. Workers share the transport, so
+// OAuth2 tokens are fetched once per call, and the limiter of a suite
+func newClients(config Config, n int, limiter chan struct{}) []*http.Client {
+	clients := make([]*http.Client, n)
+	transport := newTransport(config)
+	if config.Auth != nil {
+		transport = &authTransport{auth: *config.Auth, base: transport}
+	}
+	if limiter != nil {
+		transport = &limitTransport{base: transport, slots: limiter}
+	}
+	var shared http.CookieJar
+	for i := range clients {
+		switch config.CookieJar {
+		case CookieJarIsolated:
+			clients[i] = &http.Client{Jar: newSeededJar(config), Transport: transport}
+		case CookieJarShared:
+			if shared == nil {
+				shared = newSeededJar(config)
+			}
+			clients[i] = &http.Client{Jar: shared, Transport: transport}
+		default:
+			if transport == nil {
+				clients[i] = http.DefaultClient
+			} else if i == 0 {
+				clients[i] = &http.Client{Transport: transport}
+			} else {
+				clients[i] = clients[0]
+			}
+		}
+	}
+	return clients
+}
+
+// This func calls an URL concurrently. The i-th concurrent attempt
+// goes through clients[i], or http.DefaultClient when there is none
+func callURL(callerURL *url.URL, concurrentAttempts int, config Config, clients []*http.Client) (responses []HTTPResponse) {
 	urlResponse := make(chan HTTPResponse)
 	var wg sync.WaitGroup
 	wg.Add(concurrentAttempts)
 	for i := 0; i < int(concurrentAttempts); i++ {
+		client := http.DefaultClient
+		if i < len(clients) {
+			client = clients[i]
+		}
 		go func() {
 			defer wg.Done()
 			beginning := time.Now()
+			defer func() {
+				think(config.ThinkTime)
+				pace(beginning, config.Pacing)
+			}()
 			req, err := buildRequest(callerURL.String(), config)
 			if err != nil {
 				log.Fatalf("Something got wrong: %v", err)
 			}
-			client := http.DefaultClient
 			response, err := client.Do(req)
 			executionSecs := time.Since(beginning).Seconds()
 			if err != nil {
@@ -167,13 +307,25 @@ func callURL(callerURL *url.URL, concurrentAttempts int, config Config) (respons
 					err:       err,
 					execution: executionSecs,
 					status:    http.StatusRequestTimeout,
+					checks:    evaluateChecks(config.Checks, nil, nil, executionSecs),
 				}
 				return
 			}
+			defer response.Body.Close()
+			// the body is always read, so the execution time covers the
+			// whole response whether checks are configured or not
+			var body []byte
+			if len(config.Checks) > 0 {
+				body, err = io.ReadAll(response.Body)
+			} else {
+				_, err = io.Copy(io.Discard, response.Body)
+			}
+			executionSecs = time.Since(beginning).Seconds()
 			urlResponse <- HTTPResponse{
 				err:       err,
 				execution: executionSecs,
 				status:    response.StatusCode,
+				checks:    evaluateChecks(config.Checks, response, body, executionSecs),
 			}
 		}()
 	}
@@ -200,6 +352,8 @@ func buildRequest(baseURL string, config Config) (req *http.Request, err error)
 	if err != nil {
 		return
 	}
-	req.Header = config.Header
+	for key, values := range config.Header {
+		req.Header[key] = values
+	}
 	return
 }
@@ -86,7 +86,7 @@ func TestGetUrl(test *testing.T) {
 
 	config := Config{}
 	parsedURL, _ := url.Parse(urlAddress)
-	callResponses := callURL(parsedURL, 50, config)
+	callResponses := callURL(parsedURL, 50, config, nil)
 
 	for _, response := range callResponses {
 		assert.Equal(test, 200, response.status)
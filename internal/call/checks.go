@@ -0,0 +1,158 @@
+package call
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+// Supported Check types
+const (
+	CheckStatus          = "status"
+	CheckBodyContains    = "body_contains"
+	CheckBodyRegex       = "body_regex"
+	CheckJSONPath        = "json_path"
+	CheckHeader          = "header"
+	CheckMaxBodySize     = "max_body_size"
+	CheckMaxResponseTime = "max_response_time"
+)
+
+// A Check is an assertion evaluated against every response, so that
+// a 200 carrying an error page can still be told apart from a success
+type Check struct {
+	Name   string  `json:"name,omitempty"`
+	Type   string  `json:"type"`
+	Expr   string  `json:"expr,omitempty"`   // substring, regex, JSON path or header name
+	Equals string  `json:"equals,omitempty"` // expected value of a JSON path
+	Status []int   `json:"status,omitempty"` // accepted status codes
+	Max    float64 `json:"max,omitempty"`    // body size in bytes or response time in seconds
+
+	pattern *regexp.Regexp // compiled Expr of a body_regex check
+}
+
+// CheckBenchmark with the number of responses that passed and failed a Check
+type CheckBenchmark struct {
+	name   string // check name
+	passed int    // responses that satisfied the check
+	failed int    // responses that did not satisfy the check
+}
+
+// label returns the name used to report a check
+func (c Check) label() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	switch c.Type {
+	case CheckStatus:
+		return fmt.Sprintf("%s in %v", c.Type, c.Status)
+	case CheckJSONPath:
+		return fmt.Sprintf("%s %s == %s", c.Type, c.Expr, c.Equals)
+	case CheckMaxBodySize, CheckMaxResponseTime:
+		return c.Type + " " + strconv.FormatFloat(c.Max, 'f', -1, 64)
+	}
+	return c.Type + " " + c.Expr
+}
+
+// checkChecks validates every check of a request
+func checkChecks(checks []Check) (errs []fieldError) {
+	for i := range checks {
+		check := &checks[i]
+		field := strconv.Itoa(i)
+		switch check.Type {
+		case CheckStatus:
+			if len(check.Status) == 0 {
+				errs = append(errs, fieldError{field + ".status", fmt.Errorf("%w: status list is empty", ErrInvalidCheck)})
+			}
+		case CheckBodyContains, CheckJSONPath, CheckHeader:
+			if check.Expr == "" {
+				errs = append(errs, fieldError{field + ".expr", fmt.Errorf("%w: expr is empty", ErrInvalidCheck)})
+			}
+		case CheckBodyRegex:
+			pattern, err := regexp.Compile(check.Expr)
+			if err != nil {
+				errs = append(errs, fieldError{field + ".expr", err})
+			}
+			check.pattern = pattern
+		case CheckMaxBodySize, CheckMaxResponseTime:
+			if check.Max <= 0 {
+				errs = append(errs, fieldError{field + ".max", fmt.Errorf("%w: max must be positive", ErrInvalidCheck)})
+			}
+		default:
+			errs = append(errs, fieldError{field + ".type", fmt.Errorf("%w: unknown type %q", ErrInvalidCheck, check.Type)})
+		}
+	}
+	return
+}
+
+// newCheckBenchmarks creates an empty benchmark for every check
+func newCheckBenchmarks(checks []Check) (benchmarks []CheckBenchmark) {
+	for _, check := range checks {
+		benchmarks = append(benchmarks, CheckBenchmark{name: check.label()})
+	}
+	return
+}
+
+// evaluateChecks returns whether each check passed for a response.
+// A nil resp means the request failed before any response arrived
+func evaluateChecks(checks []Check, resp *http.Response, body []byte, execution float64) []bool {
+	if len(checks) == 0 {
+		return nil
+	}
+	passed := make([]bool, len(checks))
+	for i, check := range checks {
+		if check.Type == CheckMaxResponseTime {
+			passed[i] = execution <= check.Max
+			continue
+		}
+		if resp == nil {
+			continue
+		}
+		switch check.Type {
+		case CheckStatus:
+			for _, status := range check.Status {
+				if status == resp.StatusCode {
+					passed[i] = true
+				}
+			}
+		case CheckBodyContains:
+			passed[i] = bytes.Contains(body, []byte(check.Expr))
+		case CheckBodyRegex:
+			passed[i] = compiled(check.pattern, check.Expr).Match(body)
+		case CheckJSONPath:
+			value := gjson.GetBytes(body, check.Expr)
+			passed[i] = value.Exists() && value.String() == check.Equals
+		case CheckHeader:
+			passed[i] = len(resp.Header.Values(check.Expr)) > 0
+		case CheckMaxBodySize:
+			passed[i] = float64(len(body)) <= check.Max
+		}
+	}
+	return passed
+}
+
+// GetName returns the check name
+func (c *CheckBenchmark) GetName() string {
+	return c.name
+}
+
+// GetPassed returns how many responses passed the check
+func (c *CheckBenchmark) GetPassed() int {
+	return c.passed
+}
+
+// GetFailed returns how many responses failed the check
+func (c *CheckBenchmark) GetFailed() int {
+	return c.failed
+}
+
+// GetRatio returns the ratio of responses that passed the check
+func (c *CheckBenchmark) GetRatio() float64 {
+	if c.passed+c.failed == 0 {
+		return 0
+	}
+	return float64(c.passed) / float64(c.passed+c.failed)
+}
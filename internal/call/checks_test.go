@@ -0,0 +1,92 @@
+package call
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeCallsReportsChecksSeparatelyFromStatus(test *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://www.foo.com/bar",
+		httpmock.NewStringResponder(200, `<html>Internal error</html>`))
+
+	params := []string{"http://www.foo.com/bar", "10"}
+	call, _ := BuildCall(params, 1, 100)
+	call.SetConfig(Config{
+		Method: http.MethodGet,
+		URL:    "http://www.foo.com/bar",
+		Checks: []Check{
+			{Type: CheckStatus, Status: []int{200, 201}},
+			{Type: CheckBodyContains, Expr: "error", Name: "error page"},
+			{Type: CheckJSONPath, Expr: "ok", Equals: "true"},
+		},
+	})
+	result := call.MakeIt()
+
+	assert.Equal(test, 10, result.status[200].total)
+	checks := result.GetChecks()
+	assert.Equal(test, 3, len(checks))
+	assert.Equal(test, "status in [200 201]", checks[0].GetName())
+	assert.Equal(test, 10, checks[0].GetPassed())
+	assert.Equal(test, "error page", checks[1].GetName())
+	assert.Equal(test, 1.0, checks[1].GetRatio())
+	assert.Equal(test, 10, checks[2].GetFailed())
+	assert.Equal(test, 0.0, checks[2].GetRatio())
+}
+
+func TestEvaluateChecks(test *testing.T) {
+	resp := httpmock.NewStringResponse(201, ``)
+	resp.Header.Set("X-Request-Id", "1")
+	body := []byte(`{"user": {"id": 7}}`)
+
+	tests := []struct {
+		name  string
+		check Check
+		want  bool
+	}{
+		{"status accepted", Check{Type: CheckStatus, Status: []int{200, 201}}, true},
+		{"status rejected", Check{Type: CheckStatus, Status: []int{200}}, false},
+		{"body contains", Check{Type: CheckBodyContains, Expr: `"id"`}, true},
+		{"body regex", Check{Type: CheckBodyRegex, Expr: `"id":\s*\d+`}, true},
+		{"json path equals", Check{Type: CheckJSONPath, Expr: "user.id", Equals: "7"}, true},
+		{"json path differs", Check{Type: CheckJSONPath, Expr: "user.id", Equals: "8"}, false},
+		{"header present", Check{Type: CheckHeader, Expr: "X-Request-Id"}, true},
+		{"header missing", Check{Type: CheckHeader, Expr: "X-Trace-Id"}, false},
+		{"body size under max", Check{Type: CheckMaxBodySize, Max: 100}, true},
+		{"body size over max", Check{Type: CheckMaxBodySize, Max: 5}, false},
+		{"response time under max", Check{Type: CheckMaxResponseTime, Max: 0.5}, true},
+		{"response time over max", Check{Type: CheckMaxResponseTime, Max: 0.1}, false},
+	}
+	for _, tt := range tests {
+		test.Run(tt.name, func(t *testing.T) {
+			got := evaluateChecks([]Check{tt.check}, resp, body, 0.2)
+			assert.Equal(t, []bool{tt.want}, got)
+		})
+	}
+}
+
+func TestEvaluateChecksWithoutResponse(test *testing.T) {
+	checks := []Check{
+		{Type: CheckStatus, Status: []int{200}},
+		{Type: CheckMaxResponseTime, Max: 1},
+	}
+	assert.Equal(test, []bool{false, true}, evaluateChecks(checks, nil, nil, 0.2))
+}
+
+func TestCheckChecks(test *testing.T) {
+	assert.NotNil(test, checkChecks([]Check{{Type: "xpath"}}))
+	assert.NotNil(test, checkChecks([]Check{{Type: CheckStatus}}))
+	assert.NotNil(test, checkChecks([]Check{{Type: CheckBodyRegex, Expr: "("}}))
+	assert.NotNil(test, checkChecks([]Check{{Type: CheckMaxResponseTime}}))
+	assert.Nil(test, checkChecks([]Check{{Type: CheckHeader, Expr: "ETag"}}))
+
+	checks := []Check{{Type: CheckBodyRegex, Expr: `"id":\s*\d+`}}
+	assert.Nil(test, checkChecks(checks))
+	assert.NotNil(test, checks[0].pattern)
+	assert.Equal(test, []bool{true}, evaluateChecks(checks, httpmock.NewStringResponse(200, ``), []byte(`{"id": 7}`), 0))
+}
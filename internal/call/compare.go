@@ -0,0 +1,237 @@
+package call
+
+import (
+	"sort"
+	"strconv"
+)
+
+// DefaultTolerance is the change, in percent, a metric may worsen by
+// before a comparison flags it as a regression
+const DefaultTolerance = 10
+
+// Metrics compared between two results reports of a case, along with
+// MetricSuccess
+const (
+	MetricRPS    = "RPS"
+	MetricMean   = "MEAN"
+	MetricP50    = "P50"
+	MetricP90    = "P90"
+	MetricP95    = "P95"
+	MetricP99    = "P99"
+	MetricErrors = "ERRORS"
+)
+
+// CompareOptions tune how two runs are compared
+type CompareOptions struct {
+	Tolerance *float64 // percent a metric may worsen by, DefaultTolerance when nil
+}
+
+// A Comparison holds the deltas between the cases of a baseline run and
+// of a current run
+type Comparison struct {
+	cases     []CaseComparison
+	tolerance float64
+}
+
+// A CaseComparison holds the deltas of a case, for all its responses
+// and then for each status code. Cases found in a single run have none
+type CaseComparison struct {
+	name    string
+	missing string // "baseline" or "current" when the case is not in that run
+	deltas  []ComparedDelta
+}
+
+// A ComparedDelta is a Delta of a comparison, flagged when its change
+// is worse than the tolerance
+type ComparedDelta struct {
+	Delta
+	regression bool
+}
+
+// CompareFiles compares the JSON results reports of two runs, as
+// written by WriteResultReports
+func CompareFiles(baselinePath, currentPath string, options CompareOptions) (Comparison, error) {
+	baseline, err := ReadResultReports(baselinePath)
+	if err != nil {
+		return Comparison{}, err
+	}
+	current, err := ReadResultReports(currentPath)
+	if err != nil {
+		return Comparison{}, err
+	}
+	return CompareReports(baseline, current, options), nil
+}
+
+// CompareReports compares the cases of two runs, matched by name, or
+// by URL for unnamed cases. Cases follow the order of the current run,
+// followed by the ones only in the baseline. A delta is a regression
+// when its metric worsened by more than the tolerance: higher times,
+// error rates and rates of failing statuses, lower rates of the others
+func CompareReports(baseline, current []ResultReport, options CompareOptions) Comparison {
+	comparison := Comparison{tolerance: DefaultTolerance}
+	if options.Tolerance != nil {
+		comparison.tolerance = *options.Tolerance
+	}
+	baselineKeys := reportKeys(baseline)
+	currentKeys := reportKeys(current)
+	found := make(map[string]bool)
+	for i, report := range current {
+		key := currentKeys[i]
+		found[key] = true
+		index := indexOf(baselineKeys, key)
+		if index < 0 {
+			comparison.cases = append(comparison.cases, CaseComparison{name: key, missing: "baseline"})
+			continue
+		}
+		var deltas []ComparedDelta
+		for _, delta := range compareReports(baseline[index], report) {
+			deltas = append(deltas, ComparedDelta{Delta: delta, regression: delta.worsened(comparison.tolerance)})
+		}
+		comparison.cases = append(comparison.cases, CaseComparison{name: key, deltas: deltas})
+	}
+	for _, key := range baselineKeys {
+		if !found[key] {
+			comparison.cases = append(comparison.cases, CaseComparison{name: key, missing: "current"})
+		}
+	}
+	return comparison
+}
+
+// compareReports returns the deltas of two runs of a case, then of each
+// status code either run got. Times of a status are only compared when
+// both runs got it
+func compareReports(baseline, current ResultReport) []Delta {
+	deltas := []Delta{
+		{metric: MetricRPS, previous: baseline.RPS, current: current.RPS},
+		{metric: MetricMean, previous: baseline.Mean, current: current.Mean},
+	}
+	deltas = append(deltas, percentileDeltas("", baseline.Percentiles, current.Percentiles)...)
+	deltas = append(deltas, Delta{metric: MetricErrors, previous: baseline.ErrorRate, current: current.ErrorRate})
+
+	statuses := make(map[int][2]*StatusReport)
+	var codes []int
+	for i, reports := range [][]StatusReport{baseline.Statuses, current.Statuses} {
+		for j := range reports {
+			pair, seen := statuses[reports[j].Status]
+			if !seen {
+				codes = append(codes, reports[j].Status)
+			}
+			pair[i] = &reports[j]
+			statuses[reports[j].Status] = pair
+		}
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		pair, status := statuses[code], strconv.Itoa(code)
+		rps := Delta{metric: MetricRPS, status: status}
+		if pair[0] != nil {
+			rps.previous = pair[0].RPS
+		}
+		if pair[1] != nil {
+			rps.current = pair[1].RPS
+		}
+		deltas = append(deltas, rps)
+		if pair[0] != nil && pair[1] != nil {
+			deltas = append(deltas, Delta{metric: MetricMean, status: status, previous: pair[0].Mean, current: pair[1].Mean})
+			deltas = append(deltas, percentileDeltas(status, pair[0].Percentiles, pair[1].Percentiles)...)
+		}
+	}
+	return deltas
+}
+
+// percentileDeltas compares the percentiles of two runs
+func percentileDeltas(status string, baseline, current Percentiles) []Delta {
+	return []Delta{
+		{metric: MetricP50, status: status, previous: baseline.P50, current: current.P50},
+		{metric: MetricP90, status: status, previous: baseline.P90, current: current.P90},
+		{metric: MetricP95, status: status, previous: baseline.P95, current: current.P95},
+		{metric: MetricP99, status: status, previous: baseline.P99, current: current.P99},
+	}
+}
+
+// worsened tells whether a metric worsened by more than tolerance
+// percent. Rates of succeeding responses worsen when they drop, every
+// other metric when it grows
+func (d *Delta) worsened(tolerance float64) bool {
+	ratio := d.GetChangeRatio()
+	if d.metric == MetricRPS {
+		code, _ := strconv.Atoi(d.status)
+		if d.status == "" || code > 0 && code < 400 {
+			ratio = -ratio
+		}
+	}
+	return ratio*100 > tolerance
+}
+
+// reportKeys returns the keys matching the cases of two runs: their
+// names, or their URLs when unnamed, numbered when repeated
+func reportKeys(reports []ResultReport) []string {
+	keys := make([]string, len(reports))
+	seen := make(map[string]int)
+	for i, report := range reports {
+		key := report.Name
+		if key == "" {
+			key = report.URL
+		}
+		seen[key]++
+		if seen[key] > 1 {
+			key += " #" + strconv.Itoa(seen[key])
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+// indexOf returns the index of s in list, -1 when it is not there
+func indexOf(list []string, s string) int {
+	for i, item := range list {
+		if item == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetCases returns the comparisons of every case
+func (c *Comparison) GetCases() []CaseComparison {
+	return c.cases
+}
+
+// GetTolerance returns the percent a metric may worsen by
+func (c *Comparison) GetTolerance() float64 {
+	return c.tolerance
+}
+
+// Regressions returns how many deltas are regressions
+func (c *Comparison) Regressions() (regressions int) {
+	for _, comparison := range c.cases {
+		for _, delta := range comparison.deltas {
+			if delta.regression {
+				regressions++
+			}
+		}
+	}
+	return
+}
+
+// GetName returns the name of the case, or its URL when unnamed
+func (c *CaseComparison) GetName() string {
+	return c.name
+}
+
+// GetMissing returns the run the case is missing from, "baseline" or
+// "current", empty when both ran it
+func (c *CaseComparison) GetMissing() string {
+	return c.missing
+}
+
+// GetDeltas returns the deltas of the case
+func (c *CaseComparison) GetDeltas() []ComparedDelta {
+	return c.deltas
+}
+
+// IsRegression tells whether the change is worse than the tolerance of
+// the comparison holding the delta
+func (d *ComparedDelta) IsRegression() bool {
+	return d.regression
+}
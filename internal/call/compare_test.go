@@ -0,0 +1,116 @@
+package call
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// deltaLines formats the deltas of a case compared, one per line
+func deltaLines(c CaseComparison) (lines []string) {
+	for _, delta := range c.GetDeltas() {
+		line := fmt.Sprintf("%s %s %s %s %s", delta.GetStatus(), delta.GetMetric(),
+			delta.Format(delta.GetPrevious()), delta.Format(delta.GetCurrent()), delta.FormatChangeRatio())
+		if delta.IsRegression() {
+			line += " REGRESSION"
+		}
+		lines = append(lines, line)
+	}
+	return
+}
+
+func TestCompareReports(t *testing.T) {
+	baseline := []ResultReport{
+		{
+			Name: "home", RPS: 100, Mean: 0.2, Percentiles: Percentiles{0.2, 0.3, 0.4, 0.5}, ErrorRate: 0,
+			Statuses: []StatusReport{{Status: 200, RPS: 100, Mean: 0.2, Percentiles: Percentiles{0.2, 0.3, 0.4, 0.5}}},
+		},
+		{Name: "gone", RPS: 10},
+	}
+	current := []ResultReport{
+		{Name: "new", RPS: 10},
+		{
+			Name: "home", RPS: 95, Mean: 0.25, Percentiles: Percentiles{0.2, 0.3, 0.42, 0.6}, ErrorRate: 0.05,
+			Statuses: []StatusReport{
+				{Status: 200, RPS: 90, Mean: 0.2, Percentiles: Percentiles{0.2, 0.3, 0.4, 0.5}},
+				{Status: 503, RPS: 5, Mean: 0.5, Percentiles: Percentiles{0.5, 0.5, 0.5, 0.5}},
+			},
+		},
+	}
+
+	comparison := CompareReports(baseline, current, CompareOptions{})
+	assert.Equal(t, float64(DefaultTolerance), comparison.GetTolerance())
+	cases := comparison.GetCases()
+	assert.Len(t, cases, 3)
+	assert.Equal(t, "new", cases[0].GetName())
+	assert.Equal(t, "baseline", cases[0].GetMissing())
+	assert.Equal(t, "gone", cases[2].GetName())
+	assert.Equal(t, "current", cases[2].GetMissing())
+
+	assert.Equal(t, "home", cases[1].GetName())
+	assert.Equal(t, []string{
+		" RPS 100.00/s 95.00/s -5.00%",
+		" MEAN 0.20s 0.25s +25.00% REGRESSION",
+		" P50 0.20s 0.20s +0.00%",
+		" P90 0.30s 0.30s +0.00%",
+		" P95 0.40s 0.42s +5.00%",
+		" P99 0.50s 0.60s +20.00% REGRESSION",
+		" ERRORS 0.00% 5.00% new REGRESSION",
+		"200 RPS 100.00/s 90.00/s -10.00%",
+		"200 MEAN 0.20s 0.20s +0.00%",
+		"200 P50 0.20s 0.20s +0.00%",
+		"200 P90 0.30s 0.30s +0.00%",
+		"200 P95 0.40s 0.40s +0.00%",
+		"200 P99 0.50s 0.50s +0.00%",
+		"503 RPS 0.00/s 5.00/s new REGRESSION",
+	}, deltaLines(cases[1]))
+	assert.Equal(t, 4, comparison.Regressions())
+
+	tolerance := 4.0
+	comparison = CompareReports(baseline, current, CompareOptions{Tolerance: &tolerance})
+	assert.Equal(t, 7, comparison.Regressions())
+
+	// any worsening is a regression without tolerance
+	tolerance = 0
+	comparison = CompareReports(baseline, current, CompareOptions{Tolerance: &tolerance})
+	assert.Equal(t, float64(0), comparison.GetTolerance())
+	assert.Equal(t, 7, comparison.Regressions())
+}
+
+func TestCompareReportsUnnamed(t *testing.T) {
+	reports := []ResultReport{{URL: "http://localhost/"}, {URL: "http://localhost/"}, {Name: "named"}}
+	comparison := CompareReports(reports, reports, CompareOptions{})
+	var names []string
+	for _, c := range comparison.GetCases() {
+		names = append(names, c.GetName())
+	}
+	assert.Equal(t, []string{"http://localhost/", "http://localhost/ #2", "named"}, names)
+	assert.Equal(t, 0, comparison.Regressions())
+}
+
+func TestCompareFiles(t *testing.T) {
+	target, _ := url.Parse("http://localhost/")
+	write := func(name string, execution float64) string {
+		result := Result{URL: target, name: "home", status: make(map[int]StatusCodeBenchmark), totalExecution: 1}
+		result.record(200, execution)
+		var out bytes.Buffer
+		assert.Nil(t, WriteResultReports(&out, []Result{result}))
+		return writeConfigFile(t, name, out.String())
+	}
+	baseline, current := write("baseline.json", 0.1), write("current.json", 0.2)
+
+	tolerance := 50.0
+	comparison, err := CompareFiles(baseline, current, CompareOptions{Tolerance: &tolerance})
+	assert.Nil(t, err)
+	assert.Equal(t, 10, comparison.Regressions())
+	tolerance = 150
+	comparison, err = CompareFiles(baseline, current, CompareOptions{Tolerance: &tolerance})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, comparison.Regressions())
+
+	_, err = CompareFiles(baseline, "missing.json", CompareOptions{})
+	assert.NotNil(t, err)
+}
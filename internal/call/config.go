@@ -2,15 +2,49 @@ package call
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported config file formats
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+const (
+	// DefaultConfigPath is the config file read when none is given
+	DefaultConfigPath = "config.json"
+
+	// StdinPath is the config path that reads from the standard input
+	StdinPath = "-"
+
+	// DefaultAttempts is the number of requests of a Config without attempts
+	DefaultAttempts = 10
+
+	// DefaultConcurrentAttempts is the number of workers of a Config without concurrent
+	DefaultConcurrentAttempts = 10
 )
 
+// stdin is where StdinPath configs are read from
+var stdin io.Reader = os.Stdin
+
 // Config is the structure that defines how the user should
 // define the config.json file to make custom requests
 type Config struct {
 	Name               string              `json:"name,omitempty"`
+	Tags               []string            `json:"tags,omitempty"`
+	Group              string              `json:"group,omitempty"`
 	Func               string              `json:"func,omitempty"`
 	Method             string              `json:"method"`
 	Attempts           int                 `json:"attempts,omitempty"`
@@ -21,43 +55,242 @@ type Config struct {
 	Host               string              `json:"host"`
 	Form               string              `json:"form,omitempty"`
 	PostForm           map[string][]string `json:"postform,omitempty"`
+	Cookie             string              `json:"cookie,omitempty"`
+	CookieJar          string              `json:"cookie_jar,omitempty"`
+	Auth               *Auth               `json:"auth,omitempty"`
+	Insecure           bool                `json:"insecure,omitempty"`
+	Proxy              string              `json:"proxy,omitempty"`
+	Resolve            []string            `json:"resolve,omitempty"`
+	HTTPVersion        string              `json:"http_version,omitempty"`
+	ThinkTime          *ThinkTime          `json:"think_time,omitempty"`
+	Pacing             float64             `json:"pacing,omitempty"`
+	Checks             []Check             `json:"checks,omitempty"`
+	Steps              []Step              `json:"steps,omitempty"`
+	Mix                []MixEntry          `json:"mix,omitempty"`
+
+	environment string // name of the environment merged into the Config
 }
 
+var allowedMethods = map[string]string{
+	http.MethodGet:     "",
+	http.MethodPost:    "",
+	http.MethodPut:     "",
+	http.MethodDelete:  "",
+	http.MethodOptions: "",
+	http.MethodHead:    "",
+	http.MethodTrace:   "",
+	http.MethodConnect: "",
+	http.MethodPatch:   "",
+}
+
+func isMethodAllowed(method string) bool {
+	_, ok := allowedMethods[method]
+	return ok
+}
+
+// fieldError is a problem with a single field of a Config. The field
+// is the dotted path of its JSON keys, such as steps.0.url
+type fieldError struct {
+	field string
+	err   error
+}
+
+func (e fieldError) Error() string {
+	return e.field + ": " + e.err.Error()
+}
+
+func (e fieldError) Unwrap() error {
+	return e.err
+}
+
+// prefixErrors nests field errors under prefix
+func prefixErrors(prefix string, errs []fieldError) []fieldError {
+	for i := range errs {
+		errs[i].field = prefix + "." + errs[i].field
+	}
+	return errs
+}
+
+// CheckDefaults fills the default values of a Config and returns its
+// first problem, if any
 func (c *Config) CheckDefaults() (err error) {
-	allowedMethods := map[string]string{
-		http.MethodGet:     "",
-		http.MethodPost:    "",
-		http.MethodPut:     "",
-		http.MethodDelete:  "",
-		http.MethodOptions: "",
-		http.MethodHead:    "",
-		http.MethodTrace:   "",
-		http.MethodConnect: "",
-		http.MethodPatch:   "",
+	errs := c.validate()
+	if len(errs) == 0 {
+		return nil
 	}
-	if len(c.Name) == 0 {
-		return ErrEmptyName
+	if strings.Contains(errs[0].field, ".") {
+		return errs[0]
 	}
-	_, err = url.ParseRequestURI(c.URL)
-	if err != nil {
-		return
+	return errs[0].err
+}
+
+// validate fills the default values of a Config and returns all of
+// its problems
+func (c *Config) validate() (errs []fieldError) {
+	if len(c.Name) == 0 {
+		errs = append(errs, fieldError{"name", ErrEmptyName})
 	}
 	if c.Attempts == 0 {
-		c.Attempts = 10
+		c.Attempts = DefaultAttempts
 	}
 	if c.ConcurrentAttempts == 0 {
-		c.ConcurrentAttempts = 10
+		c.ConcurrentAttempts = DefaultConcurrentAttempts
+	}
+	if err := c.checkCookieJar(); err != nil {
+		errs = append(errs, fieldError{"cookie_jar", err})
+	}
+	errs = append(errs, c.checkTransport()...)
+	if c.Auth != nil {
+		if err := c.Auth.check(); err != nil {
+			errs = append(errs, fieldError{"auth", err})
+		}
+	}
+	if c.ThinkTime != nil {
+		if err := c.ThinkTime.check(); err != nil {
+			errs = append(errs, fieldError{"think_time", err})
+		}
+	}
+	if c.Pacing < 0 {
+		errs = append(errs, fieldError{"pacing", ErrInvalidPacing})
 	}
-	if _, ok := allowedMethods[c.Method]; !ok {
-		return ErrMethodNotAllowed
+	errs = append(errs, prefixErrors("checks", checkChecks(c.Checks))...)
+	switch {
+	case len(c.Steps) > 0 && len(c.Mix) > 0:
+		errs = append(errs, fieldError{"mix", ErrStepsWithMix})
+	case len(c.Steps) > 0:
+		errs = append(errs, prefixErrors("steps", checkSteps(c.Steps))...)
+	case len(c.Mix) > 0:
+		errs = append(errs, prefixErrors("mix", checkMix(c.Mix))...)
+	default:
+		if _, err := url.ParseRequestURI(c.URL); err != nil {
+			errs = append(errs, fieldError{"url", err})
+		}
+		if !isMethodAllowed(c.Method) {
+			errs = append(errs, fieldError{"method", ErrMethodNotAllowed})
+		}
 	}
 	return
 }
 
+// LoadConfig reads the Config entries of a JSON, YAML or TOML file,
+// detecting its format from the extension. StdinPath reads the
+// entries from the standard input, detecting the format by content
+func LoadConfig(path string) (c []Config, err error) {
+	return LoadConfigWithOptions(path, LoadOptions{})
+}
+
+// LoadConfigWithOptions reads the Config entries of a file like
+// LoadConfig, applying options
+func LoadConfigWithOptions(path string, options LoadOptions) (c []Config, err error) {
+	c, _, err = loadConfigFile(path, options)
+	return
+}
+
+// loadConfigFile reads the Config entries and the suite settings of a file
+func loadConfigFile(path string, options LoadOptions) (c []Config, suite Suite, err error) {
+	raw, err := readConfigFile(path)
+	if err != nil {
+		return
+	}
+	format, err := detectFormat(path, raw)
+	if err != nil {
+		return
+	}
+	return parseConfig(path, raw, format, options)
+}
+
+// readConfigFile reads a config file, or the standard input for StdinPath
+func readConfigFile(path string) ([]byte, error) {
+	if path == StdinPath {
+		return ioutil.ReadAll(stdin)
+	}
+	return ioutil.ReadFile(path)
+}
+
 func config() (c []Config, err error) {
-	raw, err := ioutil.ReadFile("config.json")
+	return LoadConfig(DefaultConfigPath)
+}
+
+// detectFormat guesses a config file format from its extension or,
+// when there is none, from its content
+func detectFormat(path string, raw []byte) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	case "":
+		if json.Valid(raw) {
+			return FormatJSON, nil
+		}
+		if _, err := toml.Decode(string(raw), &map[string]interface{}{}); err == nil {
+			return FormatTOML, nil
+		}
+		return FormatYAML, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownFormat, path)
+}
+
+// decodeDocument decodes a config file into a generic document, made
+// of maps, slices and scalars whatever the format
+func decodeDocument(raw []byte, format string) (doc interface{}, err error) {
+	switch format {
+	case FormatJSON:
+		err = json.Unmarshal(raw, &doc)
+	case FormatYAML:
+		err = yaml.Unmarshal(raw, &doc)
+	case FormatTOML:
+		// arrays of tables decode as []map[string]interface{}, so the
+		// table goes through JSON to get the generic shape of the others
+		var table map[string]interface{}
+		if _, err = toml.Decode(string(raw), &table); err != nil {
+			return
+		}
+		var converted []byte
+		if converted, err = json.Marshal(table); err != nil {
+			return
+		}
+		err = json.Unmarshal(converted, &doc)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+	return
+}
+
+// parseConfig decodes the Config entries of a file. Documents are
+// resolved, expanded and then converted to JSON, so the three formats
+// share field names, decoding rules, environment variables and secret
+// files. TOML has no top level arrays, so its entries live in a
+// [[cases]] array of tables. Suite settings are only read from the
+// file itself, not from the files it includes
+func parseConfig(path string, raw []byte, format string, options LoadOptions) (c []Config, suite Suite, err error) {
+	doc, err := decodeDocument(raw, format)
+	if err != nil {
+		return
+	}
+	if suite, err = decodeSuite(doc); err != nil {
+		return
+	}
+	resolved, err := resolveDocument(path, doc, make(map[string]bool))
+	if err != nil {
+		return
+	}
+	entries, err := prepareEntries(resolved, options)
+	if err != nil {
+		return
+	}
+	converted, err := json.Marshal(entries)
 	if err != nil {
 		return
 	}
-	return c, json.Unmarshal(raw, &c)
+	if err = json.Unmarshal(converted, &c); err != nil {
+		return
+	}
+	for i := range c {
+		c[i].environment = options.Env
+	}
+	c, err = options.filter(c)
+	return
 }
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -117,3 +118,55 @@ func TestConfig_checkDefaults(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfig(t *testing.T) {
+	want := []Config{
+		{
+			Name:   "test request",
+			Method: "GET",
+			URL:    "http://www.globo.com",
+		},
+	}
+	tests := []struct {
+		name    string
+		path    string
+		stdin   string
+		wantC   []Config
+		wantErr bool
+	}{
+		{name: "should read json", path: "../../examples/config.json", wantC: want},
+		{name: "should read yaml", path: "../../examples/config.yaml", wantC: want},
+		{name: "should read toml", path: "../../examples/config.toml", wantC: want},
+		{name: "should read json from stdin", path: StdinPath, stdin: `[{"name": "test request", "method": "GET", "url": "http://www.globo.com"}]`, wantC: want},
+		{name: "should read yaml from stdin", path: StdinPath, stdin: "- name: test request\n  method: GET\n  url: http://www.globo.com\n", wantC: want},
+		{name: "should read toml from stdin", path: StdinPath, stdin: "[[cases]]\nname = \"test request\"\nmethod = \"GET\"\nurl = \"http://www.globo.com\"\n", wantC: want},
+		{name: "should reject unknown extensions", path: "../../examples/usage.md", wantErr: true},
+		{name: "should return err for missing files", path: "missing.yaml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdin = strings.NewReader(tt.stdin)
+			defer func() { stdin = os.Stdin }()
+			gotC, err := LoadConfig(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotC, tt.wantC) {
+				t.Errorf("LoadConfig() = %v, want %v", gotC, tt.wantC)
+			}
+		})
+	}
+}
+
+func TestLoadConfigKeepsFieldNamesAcrossFormats(t *testing.T) {
+	stdin = strings.NewReader("- name: yaml\n  url: http://www.globo.com\n  concurrent: 3\n  think_time: 0.5\n  header:\n    Accept: [application/json]\n")
+	defer func() { stdin = os.Stdin }()
+	gotC, err := LoadConfig(StdinPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if gotC[0].ConcurrentAttempts != 3 || gotC[0].ThinkTime.Value != 0.5 || gotC[0].Header["Accept"][0] != "application/json" {
+		t.Errorf("LoadConfig() = %+v", gotC[0])
+	}
+}
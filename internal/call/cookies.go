@@ -0,0 +1,84 @@
+package call
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+)
+
+// Supported Config.CookieJar modes
+const (
+	CookieJarNone     = "none"     // stateless requests through http.DefaultClient
+	CookieJarIsolated = "isolated" // one jar per worker, each worker is a persistent user
+	CookieJarShared   = "shared"   // a single jar shared by every worker
+)
+
+// checkCookieJar validates the cookie jar mode of a Config. Seeding
+// cookies without choosing a mode implies isolated jars, and seeding
+// them with no jar at all is an error
+func (c *Config) checkCookieJar() error {
+	if c.CookieJar == "" {
+		c.CookieJar = CookieJarNone
+		if c.Cookie != "" {
+			c.CookieJar = CookieJarIsolated
+		}
+	}
+	switch c.CookieJar {
+	case CookieJarNone:
+		if c.Cookie != "" {
+			return fmt.Errorf("%w: cookie needs an isolated or shared jar", ErrInvalidCookieJar)
+		}
+		return nil
+	case CookieJarIsolated, CookieJarShared:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidCookieJar, c.CookieJar)
+}
+
+// newSeededJar creates a cookie jar holding the Config seed cookies for
+// every host the Config calls
+func newSeededJar(config Config) http.CookieJar {
+	jar, _ := cookiejar.New(nil)
+	if config.Cookie == "" {
+		return jar
+	}
+	cookies := parseCookies(config.Cookie)
+	for _, rawURL := range configURLs(config) {
+		if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+			jar.SetCookies(u, cookies)
+		}
+	}
+	return jar
+}
+
+// configURLs lists the endpoints a Config calls
+func configURLs(config Config) (urls []string) {
+	if config.URL != "" {
+		urls = append(urls, config.URL)
+	}
+	for _, step := range config.Steps {
+		urls = append(urls, step.URL)
+	}
+	for _, entry := range config.Mix {
+		urls = append(urls, entry.URL)
+	}
+	return
+}
+
+// parseCookies parses a "name=value; name2=value2" string, as given to
+// the curl -b flag or found in a Cookie header
+func parseCookies(raw string) []*http.Cookie {
+	header := http.Header{"Cookie": {raw}}
+	return (&http.Request{Header: header}).Cookies()
+}
+
+// formatCookies is the inverse of parseCookies
+func formatCookies(cookies []*http.Cookie) string {
+	pairs := make([]string, 0, len(cookies))
+	for _, cookie := range cookies {
+		pairs = append(pairs, cookie.Name+"="+cookie.Value)
+	}
+	return strings.Join(pairs, "; ")
+}
@@ -0,0 +1,108 @@
+package call
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// registerSessionResponder answers with a new session cookie when the
+// request has none, and counts how many requests were anonymous
+func registerSessionResponder(anonymous *int) {
+	httpmock.RegisterResponder("GET", "http://www.foo.com/bar",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `[]`)
+			if _, err := req.Cookie("sid"); err != nil {
+				*anonymous++
+				resp.Header.Set("Set-Cookie", "sid=1; Path=/")
+			}
+			return resp, nil
+		})
+}
+
+func TestMakeCallsWithIsolatedCookieJars(test *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	anonymous := 0
+	registerSessionResponder(&anonymous)
+
+	config := Config{Name: "session", Method: http.MethodGet, URL: "http://www.foo.com/bar", CookieJar: CookieJarIsolated}
+	assert.Nil(test, config.CheckDefaults())
+	call := ConcurrentCall{Attempts: 10, ConcurrentAttempts: 1}
+	call.URL, _ = url.Parse(config.URL)
+	call.SetConfig(config)
+	result := call.MakeIt()
+
+	assert.Equal(test, 10, result.status[200].total)
+	assert.Equal(test, 1, anonymous)
+}
+
+func TestMakeCallsWithoutCookieJars(test *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	anonymous := 0
+	registerSessionResponder(&anonymous)
+
+	params := []string{"http://www.foo.com/bar", "10"}
+	call, _ := BuildCall(params, 1, 1)
+	call.MakeIt()
+
+	assert.Equal(test, 10, anonymous)
+}
+
+func TestMakeCallsWithSeededCookies(test *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	anonymous := 0
+	registerSessionResponder(&anonymous)
+
+	config := Config{Name: "session", Method: http.MethodGet, URL: "http://www.foo.com/bar", Cookie: "sid=seed"}
+	assert.Nil(test, config.CheckDefaults())
+	assert.Equal(test, CookieJarIsolated, config.CookieJar)
+	call := ConcurrentCall{Attempts: 10, ConcurrentAttempts: 5}
+	call.URL, _ = url.Parse(config.URL)
+	call.SetConfig(config)
+	call.MakeIt()
+
+	assert.Equal(test, 0, anonymous)
+}
+
+func TestNewClients(test *testing.T) {
+	config := Config{URL: "http://www.foo.com/bar"}
+
+	config.CookieJar = CookieJarNone
+	clients := newClients(config, 2, nil)
+	assert.Equal(test, http.DefaultClient, clients[0])
+
+	config.CookieJar = CookieJarIsolated
+	clients = newClients(config, 2, nil)
+	assert.NotNil(test, clients[0].Jar)
+	assert.True(test, clients[0].Jar != clients[1].Jar)
+
+	config.CookieJar = CookieJarShared
+	clients = newClients(config, 2, nil)
+	assert.NotNil(test, clients[0].Jar)
+	assert.True(test, clients[0].Jar == clients[1].Jar)
+}
+
+func TestCheckCookieJar(test *testing.T) {
+	config := Config{CookieJar: "global"}
+	assert.NotNil(test, config.checkCookieJar())
+
+	config = Config{}
+	assert.Nil(test, config.checkCookieJar())
+	assert.Equal(test, CookieJarNone, config.CookieJar)
+
+	config = Config{Cookie: "sid=1", CookieJar: CookieJarNone}
+	assert.True(test, errors.Is(config.checkCookieJar(), ErrInvalidCookieJar))
+}
+
+func TestParseCookies(test *testing.T) {
+	cookies := parseCookies("a=1; b=2")
+	assert.Equal(test, 2, len(cookies))
+	assert.Equal(test, "a=1; b=2", formatCookies(cookies))
+}
@@ -1,240 +1,942 @@
 package call
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"unicode/utf8"
+)
+
+// curlFormBoundary separates the parts of -F bodies, fixed so imported
+// configs do not change from one import to another
+const curlFormBoundary = "call-it-form-boundary"
+
+// curlFlag tells how a curl flag is handled
+type curlFlag int
 
-	"github.com/474420502/gcurl"
+const (
+	curlHandled     curlFlag = iota // mapped to the Config
+	curlOutput                      // only changes what curl prints, ignored
+	curlUnsupported                 // changes the request but cannot be honored, reported
 )
 
-func min(a, b int) int {
-	if a < b {
-		return a
+// curlOption is a curl flag understood by ImportCurl
+type curlOption struct {
+	kind curlFlag
+	arg  bool // whether the flag takes an argument
+}
+
+// curlOptions are the curl flags understood by ImportCurl, by long name
+var curlOptions = map[string]curlOption{
+	"url":            {curlHandled, true},
+	"request":        {curlHandled, true},
+	"header":         {curlHandled, true},
+	"data":           {curlHandled, true},
+	"data-ascii":     {curlHandled, true},
+	"data-raw":       {curlHandled, true},
+	"data-binary":    {curlHandled, true},
+	"data-urlencode": {curlHandled, true},
+	"json":           {curlHandled, true},
+	"form":           {curlHandled, true},
+	"form-string":    {curlHandled, true},
+	"get":            {curlHandled, false},
+	"head":           {curlHandled, false},
+	"upload-file":    {curlHandled, true},
+	"user":           {curlHandled, true},
+	"basic":          {curlHandled, false},
+	"oauth2-bearer":  {curlHandled, true},
+	"aws-sigv4":      {curlHandled, true},
+	"cookie":         {curlHandled, true},
+	"user-agent":     {curlHandled, true},
+	"referer":        {curlHandled, true},
+	"insecure":       {curlHandled, false},
+	"compressed":     {curlHandled, false},
+	"resolve":        {curlHandled, true},
+	"proxy":          {curlHandled, true},
+	"proxy-user":     {curlHandled, true},
+	"http1.1":        {curlHandled, false},
+	"http2":          {curlHandled, false},
+	"location":       {curlHandled, false},
+
+	"silent":       {curlOutput, false},
+	"show-error":   {curlOutput, false},
+	"verbose":      {curlOutput, false},
+	"include":      {curlOutput, false},
+	"fail":         {curlOutput, false},
+	"progress-bar": {curlOutput, false},
+	"no-buffer":    {curlOutput, false},
+	"globoff":      {curlOutput, false},
+	"remote-name":  {curlOutput, false},
+	"output":       {curlOutput, true},
+	"write-out":    {curlOutput, true},
+	"dump-header":  {curlOutput, true},
+	"trace":        {curlOutput, true},
+	"trace-ascii":  {curlOutput, true},
+	"stderr":       {curlOutput, true},
+
+	"no-progress-meter":  {curlOutput, false},
+	"remote-name-all":    {curlOutput, false},
+	"remote-header-name": {curlOutput, false},
+	"remote-time":        {curlOutput, false},
+	"create-dirs":        {curlOutput, false},
+	"fail-early":         {curlOutput, false},
+	"fail-with-body":     {curlOutput, false},
+	"parallel":           {curlOutput, false},
+	"parallel-immediate": {curlOutput, false},
+	"disable":            {curlOutput, false},
+	"xattr":              {curlOutput, false},
+	"output-dir":         {curlOutput, true},
+	"create-file-mode":   {curlOutput, true},
+	"parallel-max":       {curlOutput, true},
+	"libcurl":            {curlOutput, true},
+	"etag-save":          {curlOutput, true},
+
+	"digest":                {curlUnsupported, false},
+	"ntlm":                  {curlUnsupported, false},
+	"negotiate":             {curlUnsupported, false},
+	"anyauth":               {curlUnsupported, false},
+	"http1.0":               {curlUnsupported, false},
+	"http2-prior-knowledge": {curlUnsupported, false},
+	"http3":                 {curlUnsupported, false},
+	"ipv4":                  {curlUnsupported, false},
+	"ipv6":                  {curlUnsupported, false},
+	"junk-session-cookies":  {curlUnsupported, false},
+	"location-trusted":      {curlUnsupported, false},
+	"netrc":                 {curlUnsupported, false},
+	"no-keepalive":          {curlUnsupported, false},
+	"path-as-is":            {curlUnsupported, false},
+	"proxy-insecure":        {curlUnsupported, false},
+	"max-time":              {curlUnsupported, true},
+	"connect-timeout":       {curlUnsupported, true},
+	"max-redirs":            {curlUnsupported, true},
+	"retry":                 {curlUnsupported, true},
+	"cookie-jar":            {curlUnsupported, true},
+	"cert":                  {curlUnsupported, true},
+	"key":                   {curlUnsupported, true},
+	"cacert":                {curlUnsupported, true},
+	"capath":                {curlUnsupported, true},
+	"range":                 {curlUnsupported, true},
+	"limit-rate":            {curlUnsupported, true},
+	"connect-to":            {curlUnsupported, true},
+	"interface":             {curlUnsupported, true},
+	"proxy-header":          {curlUnsupported, true},
+	"request-target":        {curlUnsupported, true},
+	"unix-socket":           {curlUnsupported, true},
+	"config":                {curlUnsupported, true},
+	"time-cond":             {curlUnsupported, true},
+	"continue-at":           {curlUnsupported, true},
+	"speed-limit":           {curlUnsupported, true},
+	"speed-time":            {curlUnsupported, true},
+
+	"retry-connrefused":         {curlUnsupported, false},
+	"retry-all-errors":          {curlUnsupported, false},
+	"proxytunnel":               {curlUnsupported, false},
+	"proxy-basic":               {curlUnsupported, false},
+	"proxy-digest":              {curlUnsupported, false},
+	"proxy-ntlm":                {curlUnsupported, false},
+	"proxy-anyauth":             {curlUnsupported, false},
+	"tlsv1":                     {curlUnsupported, false},
+	"tlsv1.0":                   {curlUnsupported, false},
+	"tlsv1.1":                   {curlUnsupported, false},
+	"tlsv1.2":                   {curlUnsupported, false},
+	"tlsv1.3":                   {curlUnsupported, false},
+	"sslv2":                     {curlUnsupported, false},
+	"sslv3":                     {curlUnsupported, false},
+	"ssl":                       {curlUnsupported, false},
+	"ssl-reqd":                  {curlUnsupported, false},
+	"ssl-no-revoke":             {curlUnsupported, false},
+	"cert-status":               {curlUnsupported, false},
+	"tcp-nodelay":               {curlUnsupported, false},
+	"tcp-fastopen":              {curlUnsupported, false},
+	"no-sessionid":              {curlUnsupported, false},
+	"no-alpn":                   {curlUnsupported, false},
+	"raw":                       {curlUnsupported, false},
+	"tr-encoding":               {curlUnsupported, false},
+	"ignore-content-length":     {curlUnsupported, false},
+	"disallow-username-in-url":  {curlUnsupported, false},
+	"suppress-connect-headers":  {curlUnsupported, false},
+	"false-start":               {curlUnsupported, false},
+	"post301":                   {curlUnsupported, false},
+	"post302":                   {curlUnsupported, false},
+	"post303":                   {curlUnsupported, false},
+	"append":                    {curlUnsupported, false},
+	"use-ascii":                 {curlUnsupported, false},
+	"list-only":                 {curlUnsupported, false},
+	"noproxy":                   {curlUnsupported, true},
+	"retry-delay":               {curlUnsupported, true},
+	"retry-max-time":            {curlUnsupported, true},
+	"quote":                     {curlUnsupported, true},
+	"max-filesize":              {curlUnsupported, true},
+	"keepalive-time":            {curlUnsupported, true},
+	"expect100-timeout":         {curlUnsupported, true},
+	"happy-eyeballs-timeout-ms": {curlUnsupported, true},
+	"dns-servers":               {curlUnsupported, true},
+	"doh-url":                   {curlUnsupported, true},
+	"local-port":                {curlUnsupported, true},
+	"proxy-cacert":              {curlUnsupported, true},
+	"proxy-cert":                {curlUnsupported, true},
+	"proxy-key":                 {curlUnsupported, true},
+	"pinnedpubkey":              {curlUnsupported, true},
+	"ciphers":                   {curlUnsupported, true},
+	"tls-max":                   {curlUnsupported, true},
+	"cert-type":                 {curlUnsupported, true},
+	"key-type":                  {curlUnsupported, true},
+	"pass":                      {curlUnsupported, true},
+	"crlfile":                   {curlUnsupported, true},
+	"socks4":                    {curlUnsupported, true},
+	"socks4a":                   {curlUnsupported, true},
+	"socks5":                    {curlUnsupported, true},
+	"socks5-hostname":           {curlUnsupported, true},
+	"preproxy":                  {curlUnsupported, true},
+	"url-query":                 {curlUnsupported, true},
+	"variable":                  {curlUnsupported, true},
+	"rate":                      {curlUnsupported, true},
+	"proto":                     {curlUnsupported, true},
+	"proto-redir":               {curlUnsupported, true},
+	"hsts":                      {curlUnsupported, true},
+	"alt-svc":                   {curlUnsupported, true},
+	"etag-compare":              {curlUnsupported, true},
+	"ftp-port":                  {curlUnsupported, true},
+	"telnet-option":             {curlUnsupported, true},
+}
+
+// curlShortOptions maps the short curl flags to their long names
+var curlShortOptions = map[byte]string{
+	'X': "request", 'H': "header", 'd': "data", 'F': "form", 'G': "get",
+	'I': "head", 'T': "upload-file", 'u': "user", 'b': "cookie", 'A': "user-agent",
+	'e': "referer", 'k': "insecure", 'x': "proxy", 'U': "proxy-user", 'L': "location",
+	's': "silent", 'S': "show-error", 'v': "verbose", 'i': "include", 'f': "fail",
+	'#': "progress-bar", 'N': "no-buffer", 'g': "globoff", 'O': "remote-name",
+	'o': "output", 'w': "write-out", 'D': "dump-header", '0': "http1.0", '4': "ipv4",
+	'6': "ipv6", 'j': "junk-session-cookies", 'n': "netrc", 'm': "max-time",
+	'c': "cookie-jar", 'E': "cert", 'r': "range", 'K': "config", 'z': "time-cond",
+	'C': "continue-at", 'Y': "speed-limit", 'y': "speed-time", 'Q': "quote",
+	'p': "proxytunnel", 'J': "remote-header-name", 'R': "remote-time", 'Z': "parallel",
+	'q': "disable", '1': "tlsv1", '2': "sslv2", '3': "sslv3", 'a': "append",
+	'B': "use-ascii", 'l': "list-only", 'P': "ftp-port", 't': "telnet-option",
+}
+
+// curlData is a piece of data given by one of the -d flags
+type curlData struct {
+	flag  string
+	value string
+}
+
+// curlImport gathers the flags of a curl command before they become a
+// Config, since some depend on others, like -G moving the data to the URL
+type curlImport struct {
+	config    Config
+	header    http.Header
+	urls      []string
+	method    string
+	data      []curlData
+	form      []string // -F arguments, --form-string ones prefixed by "="
+	get       bool
+	head      bool
+	upload    string
+	cookies   []string
+	proxy     string
+	proxyUser string
+	user      string
+	sigv4     string // aws-sigv4 provider, region and service
+	auth      string // unsupported auth scheme asked for
+	warnings  []string
+}
+
+// ImportCurl converts a curl command, such as the ones browsers copy,
+// into a Config. Every flag changing the request that cannot be
+// honored is reported by a warning rather than dropped silently
+func ImportCurl(command string) (config *Config, warnings []string, err error) {
+	words, err := splitShellWords(command)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(words) == 0 || !isCurlProgram(words[0]) {
+		return nil, nil, fmt.Errorf("%w: command must start with 'curl'", ErrInvalidCurl)
+	}
+
+	imported := &curlImport{header: make(http.Header)}
+	args := words[1:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) < 2 || arg[0] != '-' {
+			imported.urls = append(imported.urls, arg)
+			continue
+		}
+		if arg == "--" {
+			imported.urls = append(imported.urls, args[i+1:]...)
+			break
+		}
+		if strings.HasPrefix(arg, "--") {
+			name := arg[2:]
+			option, ok := curlOptions[name]
+			if !ok {
+				return nil, nil, unknownCurlFlag(arg)
+			}
+			value := ""
+			if option.arg {
+				if i+1 >= len(args) {
+					return nil, nil, fmt.Errorf("%w: %s needs an argument", ErrInvalidCurl, arg)
+				}
+				i++
+				value = args[i]
+			}
+			if err = imported.apply(name, arg, option, value); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		// short flags may be bundled, -sSL, and hold their argument, -XPOST
+		for j := 1; j < len(arg); j++ {
+			flag := "-" + arg[j:j+1]
+			name, ok := curlShortOptions[arg[j]]
+			if !ok {
+				return nil, nil, unknownCurlFlag(flag)
+			}
+			option := curlOptions[name]
+			value := ""
+			if option.arg {
+				if j+1 < len(arg) {
+					value = arg[j+1:]
+				} else if i+1 < len(args) {
+					i++
+					value = args[i]
+				} else {
+					return nil, nil, fmt.Errorf("%w: %s needs an argument", ErrInvalidCurl, flag)
+				}
+				j = len(arg)
+			}
+			if err = imported.apply(name, flag, option, value); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if err = imported.build(); err != nil {
+		return nil, nil, err
 	}
-	return b
+	return &imported.config, imported.warnings, nil
 }
 
-// ParseCurlCommand parses a curl command string and returns a Config struct
-// that can be used with the existing call-it infrastructure
+// ParseCurlCommand converts a curl command into a Config like
+// ImportCurl, ignoring its warnings
 func ParseCurlCommand(curlCommand string) (*Config, error) {
-	// Clean up the curl command - remove leading/trailing whitespace
-	originalLength := len(curlCommand)
-	curlCommand = strings.TrimSpace(curlCommand)
-	
-	// Handle multiline curl commands by processing line continuation characters
-	curlCommand = preprocessMultilineCurl(curlCommand)
-	
-	// Keep track of processing for potential debugging
-	_ = originalLength // Avoid unused variable warning
-	
-	// Parse the curl command using gcurl with fallback approaches
-	parsedCurl, err := parseWithFallbacks(curlCommand)
+	config, _, err := ImportCurl(curlCommand)
+	return config, err
+}
+
+// ValidateCurlCommand checks if a string is a curl command ImportCurl
+// converts
+func ValidateCurlCommand(command string) error {
+	_, _, err := ImportCurl(command)
+	return err
+}
+
+// unknownCurlFlag is the error of a flag ImportCurl does not know. Its
+// argument, if any, cannot be told apart from the URL, so the import
+// fails rather than guess the target
+func unknownCurlFlag(flag string) error {
+	return fmt.Errorf("%w: unknown flag %s", ErrInvalidCurl, flag)
+}
+
+// isCurlProgram tells whether the first word of a command runs curl
+func isCurlProgram(word string) bool {
+	name := strings.ToLower(filepath.Base(strings.ReplaceAll(word, `\`, "/")))
+	return name == "curl" || name == "curl.exe"
+}
+
+// warn records a warning of the import
+func (c *curlImport) warn(format string, args ...interface{}) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
+
+// apply records a flag, given as flag on the command line
+func (c *curlImport) apply(name, flag string, option curlOption, value string) (err error) {
+	switch option.kind {
+	case curlOutput:
+		return nil
+	case curlUnsupported:
+		switch name {
+		case "digest", "ntlm", "negotiate", "anyauth":
+			c.auth = name
+		default:
+			c.warn("unsupported flag %s ignored", flag)
+		}
+		return nil
+	}
+
+	switch name {
+	case "url":
+		c.urls = append(c.urls, value)
+	case "request":
+		c.method = strings.ToUpper(value)
+	case "header":
+		err = c.addHeader(value)
+	case "data", "data-ascii", "data-raw", "data-binary", "data-urlencode", "json":
+		c.data = append(c.data, curlData{flag: name, value: value})
+	case "form":
+		c.form = append(c.form, value)
+	case "form-string":
+		c.form = append(c.form, "="+value)
+	case "get":
+		c.get = true
+	case "head":
+		c.head = true
+	case "upload-file":
+		c.upload = value
+	case "user":
+		c.user = value
+	case "aws-sigv4":
+		c.sigv4 = value
+	case "oauth2-bearer":
+		c.config.Auth = &Auth{Type: AuthBearer, Token: value}
+	case "cookie":
+		err = c.addCookie(value)
+	case "user-agent":
+		c.header.Set("User-Agent", value)
+	case "referer":
+		c.header.Set("Referer", strings.TrimSuffix(value, ";auto"))
+	case "insecure":
+		c.config.Insecure = true
+	case "resolve":
+		c.config.Resolve = append(c.config.Resolve, value)
+	case "proxy":
+		c.proxy = value
+	case "proxy-user":
+		c.proxyUser = value
+	case "http1.1":
+		c.config.HTTPVersion = HTTPVersion11
+	case "http2":
+		c.config.HTTPVersion = HTTPVersion2
+	case "compressed", "location", "basic":
+		// gzip responses are asked for and decompressed, and redirects
+		// followed, by every call; basic is the only auth scheme of -u
+	}
+	return
+}
+
+// addHeader records a -H header. "Name:" removes a header curl would
+// send, which no call sends, "Name;" sends it empty and "@file" reads
+// a header per line
+func (c *curlImport) addHeader(value string) error {
+	if strings.HasPrefix(value, "@") {
+		raw, err := ioutil.ReadFile(value[1:])
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(string(raw), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				if err = c.addHeader(line); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if name := strings.TrimSuffix(value, ";"); name != value && !strings.Contains(name, ":") {
+		c.header.Add(name, "")
+		return nil
+	}
+	name, content, ok := strings.Cut(value, ":")
+	if !ok {
+		c.warn("header %q without colon ignored", value)
+		return nil
+	}
+	name, content = strings.TrimSpace(name), strings.TrimSpace(content)
+	switch {
+	case content == "":
+	case strings.EqualFold(name, "Cookie"):
+		c.cookies = append(c.cookies, content)
+	case strings.EqualFold(name, "Host"):
+		c.warn("Host header %q ignored, requests go to the host of the URL", content)
+	default:
+		c.header.Add(name, content)
+	}
+	return nil
+}
+
+// addCookie records a -b argument: cookies, name=value pairs, or else
+// a file in the Netscape format written by curl -c
+func (c *curlImport) addCookie(value string) error {
+	if strings.Contains(value, "=") {
+		c.cookies = append(c.cookies, value)
+		return nil
+	}
+	raw, err := ioutil.ReadFile(value)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse curl command: %w", err)
+		return err
+	}
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "#HttpOnly_")
+		fields := strings.Split(line, "\t")
+		if strings.HasPrefix(line, "#") || len(fields) != 7 {
+			continue
+		}
+		c.cookies = append(c.cookies, fields[5]+"="+fields[6])
 	}
+	return nil
+}
 
-	// Extract basic information
-	config := &Config{
-		Name:   "Parsed from cURL",
-		URL:    parsedCurl.ParsedURL.String(),
-		Method: strings.ToUpper(parsedCurl.Method),
+// build converts the recorded flags into the Config
+func (c *curlImport) build() error {
+	if len(c.urls) == 0 {
+		return fmt.Errorf("%w: no URL", ErrInvalidCurl)
+	}
+	if len(c.urls) > 1 {
+		c.warn("only the first of %d URLs is called", len(c.urls))
+	}
+	target := c.urls[0]
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
 	}
 
-	// Extract headers
-	if len(parsedCurl.Header) > 0 {
-		config.Header = make(map[string][]string)
-		for key, values := range parsedCurl.Header {
-			config.Header[key] = values
+	body, err := c.body()
+	if err != nil {
+		return err
+	}
+	method := http.MethodGet
+	switch {
+	case c.head:
+		method = http.MethodHead
+	case c.get:
+		if body != "" {
+			separator := "?"
+			if strings.Contains(target, "?") {
+				separator = "&"
+			}
+			target += separator + body
+			body = ""
+		}
+	case c.upload != "":
+		method = http.MethodPut
+		if body, err = c.uploadBody(); err != nil {
+			return err
+		}
+		if strings.HasSuffix(target, "/") {
+			target += url.PathEscape(filepath.Base(c.upload))
 		}
+	case len(c.data) > 0 || len(c.form) > 0:
+		method = http.MethodPost
+	}
+	if c.method != "" {
+		method = c.method
+	}
+	if c.get && len(c.form) > 0 {
+		c.warn("-F ignored with -G")
 	}
 
-	// Extract body content
-	if parsedCurl.Body != nil && parsedCurl.Body.Content != nil {
-		// Convert the body content to string
-		if bodyStr, ok := parsedCurl.Body.Content.(string); ok {
-			config.Body = bodyStr
-		} else if bodyBytes, ok := parsedCurl.Body.Content.([]byte); ok {
-			config.Body = string(bodyBytes)
+	if c.user != "" {
+		username, password, ok := strings.Cut(c.user, ":")
+		if !ok {
+			c.warn("no password given for user %q, curl would prompt for it", username)
+		}
+		c.config.Auth = &Auth{Type: AuthBasic, Username: username, Password: password}
+	}
+	if c.sigv4 != "" {
+		// provider1[:provider2[:region[:service]]], signed with the -u keys
+		parts := strings.SplitN(c.sigv4, ":", 4)
+		if len(parts) < 4 || c.config.Auth == nil || c.config.Auth.Type != AuthBasic {
+			c.warn("--aws-sigv4 %q needs a region, a service and -u keys, requests are not signed", c.sigv4)
 		} else {
-			// For other types, use the String() method
-			config.Body = parsedCurl.Body.String()
+			c.config.Auth = &Auth{Type: AuthSigV4, KeyID: c.config.Auth.Username, Secret: c.config.Auth.Password, Region: parts[2], Service: parts[3]}
+			if token := c.header.Get("X-Amz-Security-Token"); token != "" {
+				c.config.Auth.SessionToken = token
+				c.header.Del("X-Amz-Security-Token")
+			}
 		}
 	}
+	if c.auth != "" {
+		c.warn("%s authentication is not supported, basic is used", c.auth)
+	}
 
-	// Set default method if not specified
-	if config.Method == "" {
-		config.Method = "GET"
+	if scheme, rest, ok := strings.Cut(c.proxy, "://"); ok {
+		switch strings.ToLower(scheme) {
+		case "socks5h":
+			// net/http lets SOCKS5 proxies resolve host names already
+			c.proxy = "socks5://" + rest
+		case "socks4", "socks4a":
+			return fmt.Errorf("%w: %s proxies are not supported, only http, https and socks5 ones", ErrInvalidCurl, scheme)
+		}
+	}
+	if _, err := parseProxy(c.proxy); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCurl, err)
+	}
+	c.config.Proxy = c.proxy
+	if c.proxyUser != "" {
+		if c.proxy == "" {
+			c.warn("--proxy-user ignored without --proxy")
+		} else if proxy, err := parseProxy(c.proxy); err == nil {
+			username, password, _ := strings.Cut(c.proxyUser, ":")
+			proxy.User = url.UserPassword(username, password)
+			c.config.Proxy = proxy.String()
+		}
 	}
 
-	return config, nil
+	c.config.Name = "Parsed from cURL"
+	c.config.URL = target
+	c.config.Method = method
+	c.config.Body = body
+	if len(c.cookies) > 0 {
+		c.config.Cookie = formatCookies(parseCookies(strings.Join(c.cookies, "; ")))
+	}
+	if len(c.header) > 0 {
+		c.config.Header = c.header
+	}
+	return nil
 }
 
-// ValidateCurlCommand checks if a string looks like a valid curl command
-func ValidateCurlCommand(command string) error {
-	command = strings.TrimSpace(command)
-	
-	// Basic validation - should start with "curl"
-	if !strings.HasPrefix(strings.ToLower(command), "curl") {
-		return fmt.Errorf("command must start with 'curl'")
-	}
-	
-	// Handle multiline curl commands by processing line continuation characters
-	command = preprocessMultilineCurl(command)
-	
-	// Try to parse it to see if it's valid
-	_, err := gcurl.Parse(command)
+// body returns the body of the -d or -F flags, setting its content type
+// unless a header did
+func (c *curlImport) body() (string, error) {
+	if len(c.form) > 0 {
+		if len(c.data) > 0 {
+			c.warn("-d ignored with -F")
+		}
+		body, err := c.formBody()
+		if err != nil {
+			return "", err
+		}
+		contentType := c.header.Get("Content-Type")
+		if contentType == "" || strings.HasPrefix(contentType, "multipart/form-data") {
+			c.header.Set("Content-Type", "multipart/form-data; boundary="+curlFormBoundary)
+		}
+		return body, nil
+	}
+	if len(c.data) == 0 {
+		return "", nil
+	}
+
+	var body strings.Builder
+	json := false
+	for i, data := range c.data {
+		value, err := data.read()
+		if err != nil {
+			return "", err
+		}
+		// --json pieces are concatenated, the others joined like form fields
+		if i > 0 && data.flag != "json" {
+			body.WriteString("&")
+		}
+		body.WriteString(value)
+		json = json || data.flag == "json"
+	}
+	if json {
+		if c.header.Get("Content-Type") == "" {
+			c.header.Set("Content-Type", "application/json")
+		}
+		if c.header.Get("Accept") == "" {
+			c.header.Set("Accept", "application/json")
+		}
+	} else if c.header.Get("Content-Type") == "" && !c.get {
+		c.header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	return body.String(), nil
+}
+
+// read returns the content of a -d flag, reading the file it names
+// when it has one
+func (d curlData) read() (string, error) {
+	switch d.flag {
+	case "data-raw":
+		return d.value, nil
+	case "data-urlencode":
+		// content, =content, name=content, @file or name@file
+		name, content := "", d.value
+		if i := strings.IndexAny(d.value, "=@"); i >= 0 {
+			name, content = d.value[:i], d.value[i+1:]
+			if d.value[i] == '@' {
+				raw, err := ioutil.ReadFile(content)
+				if err != nil {
+					return "", err
+				}
+				content = string(raw)
+			}
+		}
+		if name != "" {
+			return name + "=" + url.QueryEscape(content), nil
+		}
+		return url.QueryEscape(content), nil
+	}
+	if !strings.HasPrefix(d.value, "@") {
+		return d.value, nil
+	}
+	raw, err := ioutil.ReadFile(d.value[1:])
 	if err != nil {
-		// Provide more specific error context
-		if strings.Contains(err.Error(), "multiple URLs") {
-			return fmt.Errorf("parsing error - this might be due to special characters in headers or URL. Try simplifying the command or using double quotes instead of single quotes: %w", err)
+		return "", err
+	}
+	if d.flag == "data" || d.flag == "data-ascii" {
+		// like curl, text data files lose their line breaks
+		return strings.NewReplacer("\r", "", "\n", "").Replace(string(raw)), nil
+	}
+	return string(raw), nil
+}
+
+// formBody returns the multipart body of the -F flags. A value @file
+// uploads the file, <file sends its content as the value, and ;type=
+// and ;filename= set the content type and file name of the part
+func (c *curlImport) formBody() (string, error) {
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+	if err := writer.SetBoundary(curlFormBoundary); err != nil {
+		return "", err
+	}
+	for _, field := range c.form {
+		literal := strings.HasPrefix(field, "=")
+		name, value, ok := strings.Cut(strings.TrimPrefix(field, "="), "=")
+		if !ok {
+			return "", fmt.Errorf("%w: -F %q is not name=content", ErrInvalidCurl, field)
+		}
+		var contentType, filename string
+		if !literal {
+			value, contentType, filename = splitFormOptions(value)
+		}
+
+		header := make(textproto.MIMEHeader)
+		disposition := fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(name))
+		content := []byte(value)
+		switch {
+		case !literal && strings.HasPrefix(value, "@"):
+			raw, err := ioutil.ReadFile(value[1:])
+			if err != nil {
+				return "", err
+			}
+			content = raw
+			if filename == "" {
+				filename = filepath.Base(value[1:])
+			}
+			if contentType == "" {
+				contentType = mime.TypeByExtension(filepath.Ext(value[1:]))
+			}
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+		case !literal && strings.HasPrefix(value, "<"):
+			raw, err := ioutil.ReadFile(value[1:])
+			if err != nil {
+				return "", err
+			}
+			content = raw
+		}
+		if filename != "" {
+			disposition += fmt.Sprintf(`; filename="%s"`, escapeQuotes(filename))
+		}
+		header.Set("Content-Disposition", disposition)
+		if contentType != "" {
+			header.Set("Content-Type", contentType)
+		}
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return "", err
+		}
+		if _, err = part.Write(content); err != nil {
+			return "", err
 		}
-		return fmt.Errorf("invalid curl command: %w", err)
 	}
-	
-	return nil
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
 }
 
-// preprocessMultilineCurl handles multiline curl commands by properly processing
-// line continuation characters and normalizing whitespace
-func preprocessMultilineCurl(command string) string {
-	// First, handle the case where textinput converted newlines to spaces
-	// Look for patterns like "\ -H" and replace with " -H"
-	command = handleTextInputProcessing(command)
-	
-	// Handle different types of line breaks and continuation characters
-	lines := strings.Split(command, "\n")
-	var processedLines []string
-	
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		
-		// Skip empty lines
-		if line == "" {
+// uploadBody returns the content of the -T file
+func (c *curlImport) uploadBody() (string, error) {
+	if c.upload == "-" || c.upload == "." {
+		return "", fmt.Errorf("%w: -T can only upload files", ErrInvalidCurl)
+	}
+	raw, err := ioutil.ReadFile(c.upload)
+	return string(raw), err
+}
+
+// splitFormOptions separates the ;type= and ;filename= options of a -F
+// value, keeping any other semicolon in the value
+func splitFormOptions(value string) (content, contentType, filename string) {
+	parts := strings.Split(value, ";")
+	content = parts[0]
+	for _, part := range parts[1:] {
+		switch {
+		case strings.HasPrefix(part, "type="):
+			contentType = strings.TrimPrefix(part, "type=")
+		case strings.HasPrefix(part, "filename="):
+			filename = strings.Trim(strings.TrimPrefix(part, "filename="), `"`)
+		default:
+			content += ";" + part
+		}
+	}
+	return
+}
+
+// escapeQuotes escapes the quotes and backslashes of a multipart name
+func escapeQuotes(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// splitShellWords splits a command line into words the way a POSIX
+// shell does: words are separated by blanks unless quoted or escaped,
+// single quotes keep everything, double quotes let backslashes escape
+// $ ` " \ and newlines, and $'...' understands C escapes. A backslash
+// ending a line continues the command, as does a backslash starting a
+// word followed by a blank, which is what pasting a multiline command
+// in a single line input leaves. Variables are not expanded
+func splitShellWords(command string) (words []string, err error) {
+	var (
+		word    strings.Builder
+		inWord  bool
+		runes   = []rune(command)
+		isBlank = func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' || r == '\r' }
+	)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case isBlank(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case r == '\\':
+			if i+1 >= len(runes) {
+				break
+			}
+			next := runes[i+1]
+			if next == '\n' || next == '\r' || (!inWord && isBlank(next)) {
+				i++
+				if next == '\r' && i+1 < len(runes) && runes[i+1] == '\n' {
+					i++
+				}
+				continue
+			}
+			word.WriteRune(next)
+			inWord = true
+			i++
+		case r == '\'':
+			end := indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated single quote", ErrInvalidCurl)
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			inWord = true
+			i = end
+		case r == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("$`\"\\\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("%w: unterminated double quote", ErrInvalidCurl)
+			}
+			inWord = true
+		case r == '$' && i+1 < len(runes) && runes[i+1] == '\'':
+			var unquoted string
+			if unquoted, i, err = unquoteANSI(runes, i+2); err != nil {
+				return nil, err
+			}
+			word.WriteString(unquoted)
+			inWord = true
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return
+}
+
+// indexRune returns the index of the first r of runes from start, -1
+// when there is none
+func indexRune(runes []rune, start int, r rune) int {
+	for i := start; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// ansiEscapes are the single character escapes of $'...' strings
+var ansiEscapes = map[rune]string{
+	'a': "\a", 'b': "\b", 'e': "\x1b", 'E': "\x1b", 'f': "\f", 'n': "\n", 'r': "\r",
+	't': "\t", 'v': "\v", '\\': "\\", '\'': "'", '"': "\"", '?': "?",
+}
+
+// unquoteANSI reads a $'...' string starting after its opening quote,
+// returning its content and the index of its closing quote
+func unquoteANSI(runes []rune, start int) (string, int, error) {
+	var s strings.Builder
+	for i := start; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\'' {
+			return s.String(), i, nil
+		}
+		if r != '\\' || i+1 >= len(runes) {
+			s.WriteRune(r)
+			continue
+		}
+		i++
+		escape := runes[i]
+		if replacement, ok := ansiEscapes[escape]; ok {
+			s.WriteString(replacement)
+			continue
+		}
+		base, size := 0, 0
+		switch {
+		case escape == 'x':
+			base, size = 16, 2
+		case escape == 'u':
+			base, size = 16, 4
+		case escape == 'U':
+			base, size = 16, 8
+		case escape >= '0' && escape <= '7':
+			base, size = 8, 3
+			i-- // the first digit is part of the number
+		default:
+			s.WriteRune('\\')
+			s.WriteRune(escape)
 			continue
 		}
-		
-		// Handle line continuation character at the end
-		if strings.HasSuffix(line, " \\") {
-			// Remove the backslash and trailing space
-			line = strings.TrimSuffix(line, " \\")
-			line = strings.TrimSpace(line)
-		} else if strings.HasSuffix(line, "\\") {
-			// Remove the backslash
-			line = strings.TrimSuffix(line, "\\")
-			line = strings.TrimSpace(line)
-		}
-		
-		// Add the line to processed lines
-		if line != "" {
-			processedLines = append(processedLines, line)
-		}
-	}
-	
-	// Join all lines with a single space
-	result := strings.Join(processedLines, " ")
-	
-	// Clean up any multiple spaces
-	for strings.Contains(result, "  ") {
-		result = strings.ReplaceAll(result, "  ", " ")
-	}
-	
-	return result
-}
-
-// handleTextInputProcessing fixes issues caused by textinput component
-// converting newlines to spaces in multiline curl commands
-func handleTextInputProcessing(command string) string {
-	// Pattern: backslash followed by space and then a curl option
-	// Replace "\ -H" with " -H", "\ -X" with " -X", etc.
-	curlOptions := []string{"-H", "-X", "-d", "--data", "--header", "-A", "--user-agent", "-u", "--user", "-b", "--cookie", "-e", "--referer"}
-	
-	result := command
-	for _, option := range curlOptions {
-		pattern := "\\ " + option
-		replacement := " " + option
-		result = strings.ReplaceAll(result, pattern, replacement)
-	}
-	
-	// Also handle the general case of "\ " at the end of arguments
-	result = strings.ReplaceAll(result, "\\ ", " ")
-	
-	return result
-}
-
-// parseWithFallbacks tries multiple parsing approaches to handle edge cases
-func parseWithFallbacks(command string) (*gcurl.CURL, error) {
-	var lastErr error
-	
-	// First attempt: Direct parsing
-	result, err := gcurl.Parse(command)
-	if err == nil {
-		return result, nil
-	}
-	lastErr = err
-	
-	// Second attempt: Convert single quotes to double quotes in headers
-	if strings.Contains(err.Error(), "multiple URLs") || strings.Contains(err.Error(), "misplaced") {
-		// Replace single quotes with double quotes only in header values
-		modified := convertQuotesInHeaders(command)
-		result, err = gcurl.Parse(modified)
-		if err == nil {
-			return result, nil
-		}
-		lastErr = err
-	}
-	
-	// Third attempt: Simplify complex headers
-	simplified := simplifyComplexHeaders(command)
-	result, err = gcurl.Parse(simplified)
-	if err == nil {
-		return result, nil
-	}
-	
-	// Return the original error if all attempts failed
-	return nil, lastErr
-}
-
-// convertQuotesInHeaders converts single quotes to double quotes in header values
-func convertQuotesInHeaders(command string) string {
-	// This is a simple approach - replace single quotes in -H arguments
-	result := command
-	
-	// Find all -H 'header: value' patterns and convert to -H "header: value"
-	parts := strings.Split(result, "-H '")
-	if len(parts) > 1 {
-		for i := 1; i < len(parts); i++ {
-			// Find the closing single quote
-			endQuote := strings.Index(parts[i], "'")
-			if endQuote != -1 {
-				// Replace the opening and closing single quotes with double quotes
-				header := parts[i][:endQuote]
-				rest := parts[i][endQuote+1:]
-				parts[i] = fmt.Sprintf(`"%s"%s`, header, rest)
-			}
-		}
-		result = strings.Join(parts, `-H `)
-	}
-	
-	return result
-}
-
-// simplifyComplexHeaders removes problematic headers that might cause parsing issues
-func simplifyComplexHeaders(command string) string {
-	// Remove headers that commonly cause issues
-	problematicHeaders := []string{
-		`-H 'sec-ch-ua: "Not)A;Brand";v="8", "Chromium";v="138", "Google Chrome";v="138"'`,
-		`-H 'priority: u=0, i'`,
-	}
-	
-	result := command
-	for _, header := range problematicHeaders {
-		result = strings.ReplaceAll(result, header, "")
-	}
-	
-	// Clean up multiple spaces
-	for strings.Contains(result, "  ") {
-		result = strings.ReplaceAll(result, "  ", " ")
-	}
-	
-	return strings.TrimSpace(result)
-}
\ No newline at end of file
+		digits := 0
+		for digits < size && i+1+digits < len(runes) && isDigitOf(runes[i+1+digits], base) {
+			digits++
+		}
+		if digits == 0 {
+			s.WriteRune('\\')
+			s.WriteRune(escape)
+			continue
+		}
+		code, _ := strconv.ParseUint(string(runes[i+1:i+1+digits]), base, 32)
+		if escape == 'x' || base == 8 {
+			s.WriteByte(byte(code))
+		} else if utf8.ValidRune(rune(code)) {
+			s.WriteRune(rune(code))
+		}
+		i += digits
+	}
+	return "", len(runes), fmt.Errorf("%w: unterminated $' quote", ErrInvalidCurl)
+}
+
+// isDigitOf tells whether r is a digit in base 8 or 16
+func isDigitOf(r rune, base int) bool {
+	switch {
+	case r >= '0' && r <= '7':
+		return true
+	case r == '8' || r == '9':
+		return base == 16
+	case (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F'):
+		return base == 16
+	}
+	return false
+}
@@ -1,7 +1,17 @@
 package call
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestParseCurlCommand(t *testing.T) {
@@ -58,23 +68,23 @@ func TestParseCurlCommand(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			config, err := ParseCurlCommand(tt.curlCommand)
-			
+
 			if tt.wantError {
 				if err == nil {
 					t.Errorf("ParseCurlCommand() expected error but got none")
 				}
 				return
 			}
-			
+
 			if err != nil {
 				t.Errorf("ParseCurlCommand() error = %v, want nil", err)
 				return
 			}
-			
+
 			if config.URL != tt.wantURL {
 				t.Errorf("ParseCurlCommand() URL = %v, want %v", config.URL, tt.wantURL)
 			}
-			
+
 			if config.Method != tt.wantMethod {
 				t.Errorf("ParseCurlCommand() Method = %v, want %v", config.Method, tt.wantMethod)
 			}
@@ -84,9 +94,9 @@ func TestParseCurlCommand(t *testing.T) {
 
 func TestValidateCurlCommand(t *testing.T) {
 	tests := []struct {
-		name        string
-		command     string
-		wantError   bool
+		name      string
+		command   string
+		wantError bool
 	}{
 		{
 			name:      "Valid curl command",
@@ -123,4 +133,237 @@ func TestValidateCurlCommand(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestParseCurlCommandSeedsCookies(t *testing.T) {
+	config, err := ParseCurlCommand(`curl -b 'sid=abc; theme=dark' https://httpbin.org/cookies`)
+	if err != nil {
+		t.Fatalf("ParseCurlCommand() error = %v, want nil", err)
+	}
+	if config.Cookie != "sid=abc; theme=dark" {
+		t.Errorf("ParseCurlCommand() Cookie = %q, want %q", config.Cookie, "sid=abc; theme=dark")
+	}
+	if _, ok := config.Header["Cookie"]; ok {
+		t.Errorf("ParseCurlCommand() kept the Cookie header")
+	}
+}
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{name: "blanks", command: "curl  -s\thttp://foo.com", want: []string{"curl", "-s", "http://foo.com"}},
+		{name: "single quotes", command: `curl -H 'sec-ch-ua: "Chromium";v="138"'`, want: []string{"curl", "-H", `sec-ch-ua: "Chromium";v="138"`}},
+		{name: "double quotes", command: `curl -d "{\"a\": \"\$1 \\ \n\"}"`, want: []string{"curl", "-d", `{"a": "$1 \ \n"}`}},
+		{name: "escapes", command: `curl http://foo.com/a\ b\&c`, want: []string{"curl", "http://foo.com/a b&c"}},
+		{name: "line continuations", command: "curl 'http://foo.com' \\\n  -H 'A: b' \\\r\n  -X POST", want: []string{"curl", "http://foo.com", "-H", "A: b", "-X", "POST"}},
+		{name: "continuations pasted in a single line", command: `curl 'http://foo.com' \ -H 'A: b' \ -X POST`, want: []string{"curl", "http://foo.com", "-H", "A: b", "-X", "POST"}},
+		{name: "ansi c quotes", command: `curl --data-raw $'{"a":"é\n\x41\101\'"}'`, want: []string{"curl", "--data-raw", "{\"a\":\"é\nAA'\"}"}},
+		{name: "adjacent quotes", command: `curl 'a'"b"c`, want: []string{"curl", "abc"}},
+		{name: "empty quotes", command: `curl ''`, want: []string{"curl", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitShellWords(tt.command)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, command := range []string{`curl 'http://foo.com`, `curl "http://foo.com`, `curl $'http://foo.com`} {
+		_, err := splitShellWords(command)
+		assert.True(t, errors.Is(err, ErrInvalidCurl), command)
+	}
+}
+
+func TestImportCurlKeepsBrowserHeaders(t *testing.T) {
+	config, warnings, err := ImportCurl(`curl 'https://www.foo.com/api?q=1' \
+  -H 'accept: */*' \
+  -H 'priority: u=0, i' \
+  -H 'sec-ch-ua: "Not)A;Brand";v="8", "Chromium";v="138", "Google Chrome";v="138"' \
+  -H 'x-empty;' \
+  -H 'x-removed:' \
+  -H 'cookie: sid=abc; theme=dark' \
+  -b 'lang=en'`)
+	assert.Nil(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, "GET", config.Method)
+	assert.Equal(t, "https://www.foo.com/api?q=1", config.URL)
+	assert.Equal(t, map[string][]string{
+		"Accept":    {"*/*"},
+		"Priority":  {"u=0, i"},
+		"Sec-Ch-Ua": {`"Not)A;Brand";v="8", "Chromium";v="138", "Google Chrome";v="138"`},
+		"X-Empty":   {""},
+	}, config.Header)
+	assert.Equal(t, "sid=abc; theme=dark; lang=en", config.Cookie)
+}
+
+func TestImportCurlData(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"body.txt":  "a=1\nb=2\n",
+		"note.txt":  "hello world",
+		"data.json": "{\"id\": 1}\n",
+	})
+	file := func(name string) string { return filepath.Join(dir, name) }
+
+	tests := []struct {
+		name       string
+		command    string
+		wantMethod string
+		wantURL    string
+		wantBody   string
+		wantType   string
+		wantAccept []string
+	}{
+		{name: "data joined", command: `curl -d a=1 --data b=2 http://foo.com`, wantMethod: "POST", wantURL: "http://foo.com", wantBody: "a=1&b=2", wantType: "application/x-www-form-urlencoded"},
+		{name: "data file loses line breaks", command: `curl -d @` + file("body.txt") + ` http://foo.com`, wantMethod: "POST", wantURL: "http://foo.com", wantBody: "a=1b=2", wantType: "application/x-www-form-urlencoded"},
+		{name: "binary data file", command: `curl --data-binary @` + file("body.txt") + ` -H 'Content-Type: text/plain' http://foo.com`, wantMethod: "POST", wantURL: "http://foo.com", wantBody: "a=1\nb=2\n", wantType: "text/plain"},
+		{name: "raw data", command: `curl --data-raw @literal http://foo.com`, wantMethod: "POST", wantURL: "http://foo.com", wantBody: "@literal", wantType: "application/x-www-form-urlencoded"},
+		{
+			name:       "urlencoded data",
+			command:    `curl --data-urlencode 'a b' --data-urlencode '=c&d' --data-urlencode 'q=e f' --data-urlencode note@` + file("note.txt") + ` http://foo.com`,
+			wantMethod: "POST", wantURL: "http://foo.com", wantBody: "a+b&c%26d&q=e+f&note=hello+world", wantType: "application/x-www-form-urlencoded",
+		},
+		{name: "json", command: `curl --json '{"a":' --json ' 1}' http://foo.com`, wantMethod: "POST", wantURL: "http://foo.com", wantBody: `{"a": 1}`, wantType: "application/json", wantAccept: []string{"application/json"}},
+		{name: "get moves data to the query", command: `curl -G -d a=1 --data-urlencode 'b=c d' 'http://foo.com/?x=0'`, wantMethod: "GET", wantURL: "http://foo.com/?x=0&a=1&b=c+d"},
+		{name: "explicit method", command: `curl -XPUT -d a=1 http://foo.com`, wantMethod: "PUT", wantURL: "http://foo.com", wantBody: "a=1", wantType: "application/x-www-form-urlencoded"},
+		{name: "head", command: `curl -I foo.com`, wantMethod: "HEAD", wantURL: "http://foo.com"},
+		{name: "upload", command: `curl -T ` + file("data.json") + ` http://foo.com/files/`, wantMethod: "PUT", wantURL: "http://foo.com/files/data.json", wantBody: "{\"id\": 1}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, warnings, err := ImportCurl(tt.command)
+			assert.Nil(t, err)
+			assert.Empty(t, warnings)
+			assert.Equal(t, tt.wantMethod, config.Method)
+			assert.Equal(t, tt.wantURL, config.URL)
+			assert.Equal(t, tt.wantBody, config.Body)
+			assert.Equal(t, tt.wantType, http.Header(config.Header).Get("Content-Type"))
+			assert.Equal(t, tt.wantAccept, config.Header["Accept"])
+		})
+	}
+}
+
+func TestImportCurlForm(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{"avatar.png": "PNG", "bio.txt": "about me"})
+	config, warnings, err := ImportCurl(`curl -F name=Ana -F 'avatar=@` + filepath.Join(dir, "avatar.png") + `' ` +
+		`-F 'bio=<` + filepath.Join(dir, "bio.txt") + `;type=text/plain' -F 'doc=@` + filepath.Join(dir, "bio.txt") + `;filename=cv.txt' ` +
+		`--form-string 'raw=@not-a-file' https://foo.com/profile`)
+	assert.Nil(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, "POST", config.Method)
+
+	mediaType, params, err := mime.ParseMediaType(http.Header(config.Header).Get("Content-Type"))
+	assert.Nil(t, err)
+	assert.Equal(t, "multipart/form-data", mediaType)
+	reader := multipart.NewReader(strings.NewReader(config.Body), params["boundary"])
+	var got []string
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		content, _ := ioutil.ReadAll(part)
+		got = append(got, fmt.Sprintf("%s|%s|%s|%s", part.FormName(), part.FileName(), part.Header.Get("Content-Type"), content))
+	}
+	assert.Equal(t, []string{
+		"name|||Ana",
+		"avatar|avatar.png|image/png|PNG",
+		"bio||text/plain|about me",
+		"doc|cv.txt|text/plain; charset=utf-8|about me",
+		"raw|||@not-a-file",
+	}, got)
+}
+
+func TestImportCurlAuthCookiesAndConnection(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"cookies.txt": "# Netscape HTTP Cookie File\n.foo.com\tTRUE\t/\tFALSE\t0\tsid\tabc\n#HttpOnly_.foo.com\tTRUE\t/\tTRUE\t0\ttoken\txyz\n",
+	})
+	config, warnings, err := ImportCurl(`curl -sSLk -u 'ana:s3cret' -b ` + filepath.Join(dir, "cookies.txt") + ` --compressed ` +
+		`--resolve foo.com:443:127.0.0.1 -x proxy.local:3128 -U 'bob:pw' --http2 -A 'agent/1.0' -e 'http://ref.com;auto' https://foo.com`)
+	assert.Nil(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, &Auth{Type: AuthBasic, Username: "ana", Password: "s3cret"}, config.Auth)
+	assert.Equal(t, "sid=abc; token=xyz", config.Cookie)
+	assert.True(t, config.Insecure)
+	assert.Equal(t, []string{"foo.com:443:127.0.0.1"}, config.Resolve)
+	assert.Equal(t, "http://bob:pw@proxy.local:3128", config.Proxy)
+	assert.Equal(t, HTTPVersion2, config.HTTPVersion)
+	assert.Equal(t, []string{"agent/1.0"}, config.Header["User-Agent"])
+	assert.Equal(t, []string{"http://ref.com"}, config.Header["Referer"])
+	assert.Nil(t, config.CheckDefaults())
+
+	config, _, err = ImportCurl(`curl --oauth2-bearer t0ken --http1.1 https://foo.com`)
+	assert.Nil(t, err)
+	assert.Equal(t, &Auth{Type: AuthBearer, Token: "t0ken"}, config.Auth)
+	assert.Equal(t, HTTPVersion11, config.HTTPVersion)
+}
+
+func TestImportCurlSigV4(t *testing.T) {
+	config, warnings, err := ImportCurl(`curl --aws-sigv4 'aws:amz:us-east-1:sqs' -u 'AKID:secret' ` +
+		`-H 'X-Amz-Security-Token: session' -H 'Accept: application/json' https://sqs.us-east-1.amazonaws.com`)
+	assert.Nil(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, &Auth{Type: AuthSigV4, KeyID: "AKID", Secret: "secret", Region: "us-east-1", Service: "sqs", SessionToken: "session"}, config.Auth)
+	assert.Equal(t, map[string][]string{"Accept": {"application/json"}}, config.Header)
+	assert.Nil(t, config.CheckDefaults())
+
+	config, warnings, err = ImportCurl(`curl --aws-sigv4 'aws:amz' -u 'AKID:secret' https://sqs.us-east-1.amazonaws.com`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`--aws-sigv4 "aws:amz" needs a region, a service and -u keys, requests are not signed`}, warnings)
+	assert.Equal(t, &Auth{Type: AuthBasic, Username: "AKID", Password: "secret"}, config.Auth)
+}
+
+func TestImportCurlWarnings(t *testing.T) {
+	config, warnings, err := ImportCurl(`curl --digest -u ana --max-time 5 -m 3 -Z --noproxy localhost --retry-delay 5 -Q NOOP -H 'Host: other.com' -H 'broken' -U bob https://foo.com https://bar.com`)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://foo.com", config.URL)
+	assert.Equal(t, []string{
+		"unsupported flag --max-time ignored",
+		"unsupported flag -m ignored",
+		"unsupported flag --noproxy ignored",
+		"unsupported flag --retry-delay ignored",
+		"unsupported flag -Q ignored",
+		`Host header "other.com" ignored, requests go to the host of the URL`,
+		`header "broken" without colon ignored`,
+		"only the first of 2 URLs is called",
+		`no password given for user "ana", curl would prompt for it`,
+		"digest authentication is not supported, basic is used",
+		"--proxy-user ignored without --proxy",
+	}, warnings)
+}
+
+func TestImportCurlErrors(t *testing.T) {
+	tests := []string{
+		`curl -X`,
+		`curl --header`,
+		`curl -s`,
+		`curl -d @/does/not/exist http://foo.com`,
+		`curl -F novalue http://foo.com`,
+		`wget http://foo.com`,
+		`curl --frobnicate localhost http://foo.com`,
+		`curl -k9 http://foo.com`,
+		`curl -x socks4://proxy.local:1080 http://foo.com`,
+		`curl -x socks4a://proxy.local:1080 http://foo.com`,
+		`curl -x ftp://proxy.local http://foo.com`,
+	}
+	for _, command := range tests {
+		_, _, err := ImportCurl(command)
+		assert.NotNil(t, err, command)
+	}
+
+	_, _, err := ImportCurl(`curl --frobnicate localhost http://foo.com`)
+	assert.True(t, errors.Is(err, ErrInvalidCurl))
+	assert.Contains(t, err.Error(), "unknown flag --frobnicate")
+	_, _, err = ImportCurl(`curl -x socks4://proxy.local:1080 http://foo.com`)
+	assert.True(t, errors.Is(err, ErrInvalidCurl))
+	assert.Contains(t, err.Error(), "socks4 proxies are not supported")
+}
+
+func TestImportCurlSocks5hProxy(t *testing.T) {
+	config, _, err := ImportCurl(`curl -x socks5h://proxy.local:1080 https://foo.com`)
+	assert.Nil(t, err)
+	assert.Equal(t, "socks5://proxy.local:1080", config.Proxy)
+	assert.Nil(t, config.CheckDefaults())
+}
@@ -0,0 +1,213 @@
+package call
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// document is the object shape of config files. Bare arrays of entries
+// are still accepted, and are the same as a document with cases only
+type document struct {
+	Defaults     map[string]interface{} `json:"defaults,omitempty"`
+	Include      []string               `json:"include,omitempty"`
+	Environments map[string]Environment `json:"environments,omitempty"`
+	Suite        *Suite                 `json:"suite,omitempty"`
+	Cases        []Config               `json:"cases"`
+}
+
+// origin locates an entry in the file declaring it
+type origin struct {
+	file  string
+	index int
+}
+
+// fileKey is a key path of a config file
+type fileKey struct {
+	file string
+	path string
+}
+
+// resolvedDocument is a config document whose includes and defaults
+// were applied
+type resolvedDocument struct {
+	cases        []interface{}
+	origins      []origin               // file declaring each case
+	files        []string               // config files read, the document's own first
+	environments map[string]interface{} // environments of every file
+	unknown      []fileKey              // keys matching no field, found before merging
+}
+
+// includeError is an error with an included file
+type includeError struct {
+	file string
+	pos  position
+	err  error
+}
+
+func (e *includeError) Error() string {
+	return e.file + ": " + e.err.Error()
+}
+
+func (e *includeError) Unwrap() error {
+	return e.err
+}
+
+// resolveDocument gathers the entries of a decoded config document and
+// of the files it includes, which come first. Included files may be in
+// any format and their paths, which may be globs, are relative to the
+// including file. Every entry inherits the fields of the defaults it
+// lacks, objects being merged key by key. An included file keeps its
+// own defaults and environments, overridden by those of the includer.
+// parents holds the files being resolved, to detect include cycles
+func resolveDocument(path string, doc interface{}, parents map[string]bool) (resolved resolvedDocument, err error) {
+	resolved.unknown = documentUnknownKeys(path, doc)
+	var (
+		cases    []interface{}
+		defaults map[string]interface{}
+		includes []string
+	)
+	switch node := doc.(type) {
+	case []interface{}:
+		cases = node
+	case map[string]interface{}:
+		var ok bool
+		if cases, ok = node["cases"].([]interface{}); !ok && node["cases"] != nil {
+			return resolved, fmt.Errorf("%w: cases must be an array", ErrInvalidDocument)
+		}
+		if defaults, ok = node["defaults"].(map[string]interface{}); !ok && node["defaults"] != nil {
+			return resolved, fmt.Errorf("%w: defaults must be an object", ErrInvalidDocument)
+		}
+		if resolved.environments, ok = node["environments"].(map[string]interface{}); !ok && node["environments"] != nil {
+			return resolved, fmt.Errorf("%w: environments must be an object", ErrInvalidDocument)
+		}
+		if includes, err = includePaths(path, node["include"]); err != nil {
+			return
+		}
+	case nil:
+	default:
+		return resolved, fmt.Errorf("%w: expected an array of cases or an object", ErrInvalidDocument)
+	}
+	if resolved.environments == nil {
+		resolved.environments = make(map[string]interface{})
+	}
+
+	if path != StdinPath {
+		resolved.files = append(resolved.files, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil && path != StdinPath {
+		parents[abs] = true
+		defer delete(parents, abs)
+	}
+	for _, include := range includes {
+		abs, err := filepath.Abs(include)
+		if err != nil {
+			return resolved, err
+		}
+		if parents[abs] {
+			return resolved, &includeError{file: include, err: ErrIncludeCycle}
+		}
+		included, err := resolveFile(include, parents)
+		if err != nil {
+			return resolved, err
+		}
+		resolved.cases = append(resolved.cases, included.cases...)
+		resolved.origins = append(resolved.origins, included.origins...)
+		resolved.unknown = append(resolved.unknown, included.unknown...)
+		resolved.files = append(resolved.files, included.files...)
+		mergeDefaults(resolved.environments, included.environments)
+	}
+	for i, entry := range cases {
+		resolved.cases = append(resolved.cases, entry)
+		resolved.origins = append(resolved.origins, origin{file: path, index: i})
+	}
+	for _, entry := range resolved.cases {
+		if object, ok := entry.(map[string]interface{}); ok {
+			mergeDefaults(object, defaults)
+		}
+	}
+	return
+}
+
+// resolveFile reads, decodes and resolves an included config file
+func resolveFile(path string, parents map[string]bool) (resolved resolvedDocument, err error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	format, err := detectFormat(path, raw)
+	if err != nil {
+		return
+	}
+	doc, err := decodeDocument(raw, format)
+	if err != nil {
+		return resolved, &includeError{file: path, pos: syntaxErrorPosition(raw, err), err: err}
+	}
+	return resolveDocument(path, doc, parents)
+}
+
+// includePaths returns the files matching the include directive of a
+// document, either a path or a list of them. Environment variables
+// are interpolated and globs expanded
+func includePaths(path string, directive interface{}) (paths []string, err error) {
+	var patterns []string
+	switch node := directive.(type) {
+	case nil:
+		return
+	case string:
+		patterns = []string{node}
+	case []interface{}:
+		for _, item := range node {
+			pattern, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: include must hold paths", ErrInvalidDocument)
+			}
+			patterns = append(patterns, pattern)
+		}
+	default:
+		return nil, fmt.Errorf("%w: include must hold paths", ErrInvalidDocument)
+	}
+	dir := filepath.Dir(path)
+	if path == StdinPath {
+		if dir, err = os.Getwd(); err != nil {
+			return
+		}
+	}
+	for _, pattern := range patterns {
+		if pattern, err = interpolateEnv(pattern, nil); err != nil {
+			return
+		}
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		if !strings.ContainsAny(pattern, "*?[") {
+			paths = append(paths, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	return
+}
+
+// mergeDefaults adds to entry the fields of defaults it lacks. Objects
+// present in both are merged the same way
+func mergeDefaults(entry, defaults map[string]interface{}) {
+	for key, value := range defaults {
+		current, ok := entry[key]
+		if !ok {
+			entry[key] = deepCopy(value)
+			continue
+		}
+		currentObject, currentIsObject := current.(map[string]interface{})
+		defaultObject, defaultIsObject := value.(map[string]interface{})
+		if currentIsObject && defaultIsObject {
+			mergeDefaults(currentObject, defaultObject)
+		}
+	}
+}
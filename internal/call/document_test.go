@@ -0,0 +1,128 @@
+package call
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeConfigFiles writes config files in a temporary directory,
+// returning its path
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "call-it")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0700)
+		if err = ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadConfigWithDefaults(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{
+  "defaults": {
+    "method": "GET",
+    "attempts": 50,
+    "header": {"Accept": ["application/json"], "X-Team": ["qa"]},
+    "auth": {"type": "bearer", "token": "t0ken"}
+  },
+  "cases": [
+    {"name": "users", "url": "http://www.foo.com/users"},
+    {"name": "create", "method": "POST", "url": "http://www.foo.com/users", "header": {"Accept": ["*/*"]}}
+  ]
+}`)
+	gotC, err := LoadConfig(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "GET", gotC[0].Method)
+	assert.Equal(t, 50, gotC[0].Attempts)
+	assert.Equal(t, map[string][]string{"Accept": {"application/json"}, "X-Team": {"qa"}}, gotC[0].Header)
+	assert.Equal(t, "POST", gotC[1].Method)
+	assert.Equal(t, map[string][]string{"Accept": {"*/*"}, "X-Team": {"qa"}}, gotC[1].Header)
+	assert.Equal(t, &Auth{Type: AuthBearer, Token: "t0ken"}, gotC[1].Auth)
+}
+
+func TestLoadConfigWithIncludes(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml":  "include:\n  - common.json\n  - cases/*.toml\ndefaults:\n  attempts: 5\nenvironments:\n  staging:\n    variables:\n      HOST: staging.foo.com\ncases:\n  - name: local\n    url: http://${HOST}/local\n",
+		"common.json":  `{"defaults": {"method": "GET", "attempts": 1}, "environments": {"staging": {"variables": {"HOST": "common.foo.com"}}, "qa": {}}, "cases": [{"name": "common", "url": "http://${HOST}/common"}]}`,
+		"cases/a.toml": "[[cases]]\nname = \"a\"\nmethod = \"DELETE\"\nurl = \"http://${HOST}/a\"\n",
+		"cases/b.toml": "[[cases]]\nname = \"b\"\nurl = \"http://${HOST}/b\"\n",
+	})
+	gotC, err := LoadConfigWithOptions(filepath.Join(dir, "config.yaml"), LoadOptions{Env: "staging"})
+	assert.Nil(t, err)
+
+	var names, urls, methods []string
+	var attempts []int
+	for _, c := range gotC {
+		names = append(names, c.Name)
+		urls = append(urls, c.URL)
+		methods = append(methods, c.Method)
+		attempts = append(attempts, c.Attempts)
+	}
+	assert.Equal(t, []string{"common", "a", "b", "local"}, names)
+	assert.Equal(t, []string{"http://staging.foo.com/common", "http://staging.foo.com/a", "http://staging.foo.com/b", "http://staging.foo.com/local"}, urls)
+	assert.Equal(t, []string{"GET", "DELETE", "", ""}, methods)
+	assert.Equal(t, []int{1, 5, 5, 5}, attempts)
+
+	_, err = LoadConfigWithOptions(filepath.Join(dir, "config.yaml"), LoadOptions{Env: "qa"})
+	assert.True(t, errors.Is(err, ErrUndefinedVariable))
+}
+
+func TestLoadConfigWithIncludeCycle(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"a.json":     `{"include": ["sub/b.json"], "cases": []}`,
+		"sub/b.json": `{"include": "../a.json", "cases": []}`,
+	})
+	_, err := LoadConfig(filepath.Join(dir, "a.json"))
+	assert.True(t, errors.Is(err, ErrIncludeCycle))
+}
+
+func TestLoadConfigWithMissingInclude(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{"include": ["missing.json"], "cases": []}`)
+	_, err := LoadConfig(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLoadConfigWithInvalidBlocks(t *testing.T) {
+	tests := []string{
+		`{"cases": {"name": "not an array"}}`,
+		`{"defaults": [], "cases": []}`,
+		`{"include": [1], "cases": []}`,
+		`{"environments": "staging", "cases": []}`,
+	}
+	for _, content := range tests {
+		_, err := LoadConfig(writeConfigFile(t, "config.json", content))
+		assert.True(t, errors.Is(err, ErrInvalidDocument), content)
+	}
+}
+
+func TestValidateConfigFileReportsIncludedFiles(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.json":  `{"include": ["good.json", "bad.yaml"], "defaults": {"method": "GET", "retry": 1}, "cases": []}`,
+		"good.json":    `[{"name": "good", "url": "http://www.foo.com"}]`,
+		"bad.yaml":     "- name: bad\n  url: http://www.foo.com\n  method: FETCH\n  timeout: 1\n",
+		"broken.json":  `[{"name": }]`,
+		"include.json": `{"include": "broken.json"}`,
+	})
+	problems, err := ValidateConfigFile(filepath.Join(dir, "config.json"), LoadOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"config.json:1:70: warning: defaults.retry: unknown key",
+		"bad.yaml:3:3: error: entry 1 (bad): method: Method not allowed",
+		"bad.yaml:4:3: warning: entry 1 (bad): timeout: unknown key",
+	}, problemStrings(problems))
+
+	problems, err = ValidateConfigFile(filepath.Join(dir, "include.json"), LoadOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"broken.json:1:11: error: invalid character '}' looking for beginning of value"}, problemStrings(problems))
+}
@@ -0,0 +1,120 @@
+package call
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// An Environment adapts every entry of a config file to a target, such
+// as staging or production, so one file serves them all
+type Environment struct {
+	Variables map[string]string      `json:"variables,omitempty"` // ${VAR} values, before the process environment
+	Override  map[string]interface{} `json:"override,omitempty"`  // deep-merged into every entry
+}
+
+// LoadOptions tune how the entries of a config file are loaded
+type LoadOptions struct {
+	Env     string   // environment applied to every entry, none when empty
+	Only    []string // name globs of the entries to keep, all when empty
+	Tags    []string // tags of the entries to keep, all when empty
+	Exclude []string // name globs or tags of the entries to drop
+}
+
+// prepareEntries returns the entries of a resolved config document,
+// ready to be converted into Configs: the selected environment is
+// merged into each of them and they are expanded
+func prepareEntries(resolved resolvedDocument, options LoadOptions) ([]interface{}, error) {
+	env, err := applyEnvironment(resolved, options)
+	if err != nil {
+		return nil, err
+	}
+	expanded, err := expand(resolved.cases, env.Variables)
+	if err != nil {
+		return nil, err
+	}
+	entries, _ := expanded.([]interface{})
+	return entries, nil
+}
+
+// applyEnvironment merges the selected environment into every entry of
+// a resolved config document, returning it for its variables
+func applyEnvironment(resolved resolvedDocument, options LoadOptions) (Environment, error) {
+	env, err := selectEnvironment(resolved, options.Env)
+	if err != nil {
+		return env, err
+	}
+	if options.Env != "" {
+		for _, entry := range resolved.cases {
+			if object, ok := entry.(map[string]interface{}); ok {
+				mergeOverride(object, env.Override)
+			}
+		}
+	}
+	return env, nil
+}
+
+// selectEnvironment returns the environment named name of a resolved
+// config document, an empty one when name is empty
+func selectEnvironment(resolved resolvedDocument, name string) (env Environment, err error) {
+	converted, err := json.Marshal(resolved.environments)
+	if err != nil {
+		return
+	}
+	var environments map[string]Environment
+	if err = json.Unmarshal(converted, &environments); err != nil {
+		return env, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	if name == "" {
+		return
+	}
+	env, ok := environments[name]
+	if !ok {
+		return env, fmt.Errorf("%w: %q, defined: %s", ErrUnknownEnvironment, name, strings.Join(environmentNames(environments), ", "))
+	}
+	return
+}
+
+// environmentNames returns the names of environments, sorted
+func environmentNames(environments map[string]Environment) (names []string) {
+	for name := range environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// mergeOverride deep-merges override into entry: objects are merged
+// key by key, any other value replaces the one of the entry
+func mergeOverride(entry, override map[string]interface{}) {
+	for key, value := range override {
+		if overrideObject, ok := value.(map[string]interface{}); ok {
+			if entryObject, ok := entry[key].(map[string]interface{}); ok {
+				mergeOverride(entryObject, overrideObject)
+				continue
+			}
+		}
+		entry[key] = deepCopy(value)
+	}
+}
+
+// deepCopy copies the objects and arrays of a decoded document, so
+// entries sharing an override do not share its values
+func deepCopy(value interface{}) interface{} {
+	switch node := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(node))
+		for key, child := range node {
+			copied[key] = deepCopy(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(node))
+		for i, child := range node {
+			copied[i] = deepCopy(child)
+		}
+		return copied
+	}
+	return value
+}
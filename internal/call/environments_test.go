@@ -0,0 +1,129 @@
+package call
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const environmentsConfig = `{
+  "environments": {
+    "dev": {"variables": {"BASE_URL": "http://localhost:8080"}},
+    "staging": {
+      "variables": {"BASE_URL": "https://staging.example.com"},
+      "override": {
+        "concurrent": 5,
+        "header": {"X-Env": ["staging"]},
+        "auth": {"token": "staging-t0ken"}
+      }
+    }
+  },
+  "cases": [
+    {
+      "name": "users",
+      "method": "GET",
+      "url": "${BASE_URL}/users",
+      "header": {"Accept": ["application/json"]},
+      "auth": {"type": "bearer", "token": "dev-t0ken"}
+    },
+    {"name": "health", "method": "GET", "url": "${BASE_URL}/health"}
+  ]
+}`
+
+func TestLoadConfigWithEnvironment(t *testing.T) {
+	path := writeConfigFile(t, "config.json", environmentsConfig)
+
+	gotC, err := LoadConfigWithOptions(path, LoadOptions{Env: "staging"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://staging.example.com/users", gotC[0].URL)
+	assert.Equal(t, 5, gotC[0].ConcurrentAttempts)
+	assert.Equal(t, map[string][]string{"Accept": {"application/json"}, "X-Env": {"staging"}}, gotC[0].Header)
+	assert.Equal(t, &Auth{Type: AuthBearer, Token: "staging-t0ken"}, gotC[0].Auth)
+	assert.Equal(t, "staging", gotC[0].environment)
+
+	assert.Equal(t, "https://staging.example.com/health", gotC[1].URL)
+	assert.Equal(t, map[string][]string{"X-Env": {"staging"}}, gotC[1].Header)
+	assert.Equal(t, &Auth{Token: "staging-t0ken"}, gotC[1].Auth)
+
+	gotC, err = LoadConfigWithOptions(path, LoadOptions{Env: "dev"})
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:8080/users", gotC[0].URL)
+	assert.Equal(t, 0, gotC[0].ConcurrentAttempts)
+}
+
+func TestEnvironmentVariablesTakePrecedence(t *testing.T) {
+	os.Setenv("BASE_URL", "http://from.process")
+	defer os.Unsetenv("BASE_URL")
+	path := writeConfigFile(t, "config.json", environmentsConfig)
+
+	gotC, err := LoadConfigWithOptions(path, LoadOptions{Env: "dev"})
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:8080/users", gotC[0].URL)
+
+	gotC, err = LoadConfig(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "http://from.process/users", gotC[0].URL)
+	assert.Equal(t, "", gotC[0].environment)
+}
+
+func TestLoadConfigWithUnknownEnvironment(t *testing.T) {
+	path := writeConfigFile(t, "config.json", environmentsConfig)
+	_, err := LoadConfigWithOptions(path, LoadOptions{Env: "prod"})
+	assert.True(t, errors.Is(err, ErrUnknownEnvironment))
+	assert.Contains(t, err.Error(), "dev, staging")
+}
+
+func TestLoadConfigWithInvalidDocument(t *testing.T) {
+	stdin = strings.NewReader(`"cases"`)
+	defer func() { stdin = os.Stdin }()
+	_, err := LoadConfig(StdinPath)
+	assert.True(t, errors.Is(err, ErrInvalidDocument))
+}
+
+func TestMergeOverrideDoesNotShareValues(t *testing.T) {
+	override := map[string]interface{}{"header": map[string]interface{}{"X-Env": []interface{}{"qa"}}}
+	first := map[string]interface{}{}
+	second := map[string]interface{}{"header": map[string]interface{}{"Accept": "*/*"}}
+	mergeOverride(first, override)
+	mergeOverride(second, override)
+
+	first["header"].(map[string]interface{})["X-Env"].([]interface{})[0] = "changed"
+	assert.Equal(t, []interface{}{"qa"}, override["header"].(map[string]interface{})["X-Env"])
+	assert.Equal(t, map[string]interface{}{"Accept": "*/*", "X-Env": []interface{}{"qa"}}, second["header"])
+}
+
+func TestResultHoldsEnvironment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	os.Setenv("CALL_IT_TARGET", server.URL)
+	defer os.Unsetenv("CALL_IT_TARGET")
+	path := writeConfigFile(t, "config.yaml", "environments:\n  local:\n    override:\n      attempts: 2\ncases:\n  - name: local\n    method: GET\n    url: ${CALL_IT_TARGET}\n")
+
+	calls, err := BuildCallsWithOptions(path, LoadOptions{Env: "local"})
+	assert.Nil(t, err)
+	result := calls[0].MakeIt()
+	assert.Equal(t, "local", result.GetEnvironment())
+	assert.Equal(t, 2, result.status[200].total)
+}
+
+func TestValidateConfigFileChecksOverrides(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{
+  "environments": {
+    "prod": {"override": {"concurent": 5}}
+  },
+  "cases": [{"name": "ok", "method": "GET", "url": "http://www.globo.com", "retries": 1}],
+  "setup": {}
+}`)
+	problems, err := ValidateConfigFile(path, LoadOptions{Env: "prod"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"config.json:3:27: warning: environments.prod.override.concurent: unknown key",
+		"config.json:6:3: warning: setup: unknown key",
+		"config.json:5:76: warning: entry 0 (ok): retries: unknown key",
+	}, problemStrings(problems))
+}
@@ -0,0 +1,140 @@
+package call
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// curlSafe are the characters shell words may hold unquoted
+const curlSafe = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./:=@%+,"
+
+// ExportCurl converts a Config into an equivalent curl command, shell
+// quoted and with an option per line, the inverse of ImportCurl.
+// Scenarios and traffic mixes give a command per step or entry, each
+// after a comment naming it. Settings that change the request but
+// cannot be expressed with curl are reported by warnings. Secrets are
+// kept, so the command can be run
+func ExportCurl(config Config) (command string, warnings []string) {
+	requests := []Step{{Name: config.Name, Method: config.Method, URL: config.URL, Body: config.Body, Header: config.Header}}
+	switch {
+	case len(config.Steps) > 0:
+		requests = config.Steps
+	case len(config.Mix) > 0:
+		requests = make([]Step, len(config.Mix))
+		for i, entry := range config.Mix {
+			requests[i] = entry.Step
+		}
+	}
+	if config.Func != "" {
+		warnings = append(warnings, "func is evaluated when the call runs, the body keeps its verb")
+	}
+	if config.Auth != nil {
+		switch config.Auth.Type {
+		case AuthOAuth2, AuthHMAC:
+			warnings = append(warnings, fmt.Sprintf("%s auth cannot be expressed with curl, requests are not authenticated", config.Auth.Type))
+		}
+	}
+
+	commands := make([]string, len(requests))
+	variables := false
+	for i, step := range requests {
+		commands[i] = curlCommand(config, step)
+		if len(requests) > 1 {
+			name := step.Name
+			if name == "" {
+				name = fmt.Sprintf("%d", i+1)
+			}
+			commands[i] = "# " + name + "\n" + commands[i]
+		}
+		variables = variables || len(config.Steps) > 0 && variablePattern.MatchString(commands[i])
+	}
+	if variables {
+		warnings = append(warnings, "{{var}} variables are set while the scenario runs and are kept as is")
+	}
+	return strings.Join(commands, "\n\n"), warnings
+}
+
+// curlCommand returns the curl command of a request of a Config, which
+// holds the settings shared by all its requests
+func curlCommand(config Config, request Step) string {
+	args := []string{"curl " + shellQuote(request.URL)}
+	option := func(flag string, values ...string) {
+		for i := range values {
+			values[i] = shellQuote(values[i])
+		}
+		args = append(args, strings.Join(append([]string{flag}, values...), " "))
+	}
+
+	method := strings.ToUpper(request.Method)
+	switch {
+	case method == "" || method == http.MethodGet && request.Body == "":
+	case method == http.MethodHead && request.Body == "":
+		option("-I")
+	case method == http.MethodPost && request.Body != "":
+	default:
+		option("-X", method)
+	}
+
+	names := make([]string, 0, len(request.Header))
+	for name := range request.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range request.Header[name] {
+			if value == "" {
+				option("-H", name+";")
+			} else {
+				option("-H", name+": "+value)
+			}
+		}
+	}
+	if config.Cookie != "" {
+		option("-b", config.Cookie)
+	}
+	if auth := config.Auth; auth != nil {
+		switch auth.Type {
+		case AuthBasic:
+			option("-u", auth.Username+":"+auth.Password)
+		case AuthBearer:
+			option("--oauth2-bearer", auth.Token)
+		case AuthSigV4:
+			option("--aws-sigv4", "aws:amz:"+auth.Region+":"+auth.Service)
+			option("-u", auth.KeyID+":"+auth.Secret)
+			if auth.SessionToken != "" {
+				option("-H", "X-Amz-Security-Token: "+auth.SessionToken)
+			}
+		}
+	}
+	if request.Body != "" {
+		option("--data-raw", request.Body)
+	}
+
+	if config.Insecure {
+		option("-k")
+	}
+	if config.Proxy != "" {
+		option("-x", config.Proxy)
+	}
+	for _, entry := range config.Resolve {
+		option("--resolve", entry)
+	}
+	switch config.HTTPVersion {
+	case HTTPVersion11:
+		option("--http1.1")
+	case HTTPVersion2:
+		option("--http2")
+	}
+	return strings.Join(args, " \\\n  ")
+}
+
+// shellQuote quotes a word for POSIX shells, leaving the words made of
+// safe characters as they are
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, curlSafe) == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
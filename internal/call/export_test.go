@@ -0,0 +1,135 @@
+package call
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{word: "http://foo.com/a-b_c.d", want: "http://foo.com/a-b_c.d"},
+		{word: "", want: "''"},
+		{word: "http://foo.com/?a=1&b=2", want: "'http://foo.com/?a=1&b=2'"},
+		{word: "it's", want: `'it'\''s'`},
+		{word: "$HOME `id` \\ \"x\"\nnext", want: "'$HOME `id` \\ \"x\"\nnext'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			assert.Equal(t, tt.want, shellQuote(tt.word))
+		})
+	}
+
+	// the shell reads back every word unchanged
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell")
+	}
+	words := []string{"it's", "$HOME", "a b\tc\nd", `{"a": "b\\n"}`, "", "ü ✓"}
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = shellQuote(word)
+	}
+	out, err := exec.Command(sh, "-c", `printf '%s\0' `+strings.Join(quoted, " ")).Output()
+	assert.Nil(t, err)
+	assert.Equal(t, words, strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00"))
+}
+
+func TestExportCurl(t *testing.T) {
+	command, warnings := ExportCurl(Config{Method: "GET", URL: "http://foo.com/a"})
+	assert.Equal(t, "curl http://foo.com/a", command)
+	assert.Empty(t, warnings)
+
+	command, warnings = ExportCurl(Config{
+		Method:      "POST",
+		URL:         "https://foo.com/api?x=1",
+		Body:        `{"name": "O'Neil"}`,
+		Header:      map[string][]string{"Content-Type": {"application/json"}, "X-Empty": {""}, "Accept": {"a", "b"}},
+		Cookie:      "sid=abc; theme=dark",
+		Auth:        &Auth{Type: AuthBasic, Username: "ana", Password: "s3cret"},
+		Insecure:    true,
+		Proxy:       "http://proxy.local:3128",
+		Resolve:     []string{"foo.com:443:127.0.0.1"},
+		HTTPVersion: HTTPVersion11,
+	})
+	assert.Empty(t, warnings)
+	assert.Equal(t, `curl 'https://foo.com/api?x=1' \
+  -H 'Accept: a' \
+  -H 'Accept: b' \
+  -H 'Content-Type: application/json' \
+  -H 'X-Empty;' \
+  -b 'sid=abc; theme=dark' \
+  -u ana:s3cret \
+  --data-raw '{"name": "O'\''Neil"}' \
+  -k \
+  -x http://proxy.local:3128 \
+  --resolve foo.com:443:127.0.0.1 \
+  --http1.1`, command)
+}
+
+func TestExportCurlRoundTrip(t *testing.T) {
+	tests := []Config{
+		{Method: "GET", URL: "http://foo.com/search?q=a+b&page=2"},
+		{Method: "HEAD", URL: "http://foo.com"},
+		{Method: "DELETE", URL: "http://foo.com/items/1", Auth: &Auth{Type: AuthBearer, Token: "t0ken"}},
+		{
+			Method: "PUT", URL: "https://foo.com/items/1", Body: "line 1\nline 'two'\n",
+			Header:      map[string][]string{"Content-Type": {"text/plain"}, "Sec-Ch-Ua": {`"Chromium";v="138"`}},
+			Cookie:      "sid=abc",
+			HTTPVersion: HTTPVersion2,
+		},
+		{
+			Method: "POST", URL: "https://sqs.us-east-1.amazonaws.com/", Body: "Action=ListQueues",
+			Header: map[string][]string{"Content-Type": {"application/x-www-form-urlencoded"}},
+			Auth:   &Auth{Type: AuthSigV4, KeyID: "AKID", Secret: "secret", Region: "us-east-1", Service: "sqs", SessionToken: "session"},
+		},
+	}
+	for _, want := range tests {
+		t.Run(want.Method+" "+want.URL, func(t *testing.T) {
+			command, warnings := ExportCurl(want)
+			assert.Empty(t, warnings)
+			got, warnings, err := ImportCurl(command)
+			assert.Nil(t, err)
+			assert.Empty(t, warnings)
+			assert.Equal(t, want.Method, got.Method)
+			assert.Equal(t, want.URL, got.URL)
+			assert.Equal(t, want.Body, got.Body)
+			if len(want.Header) > 0 {
+				assert.Equal(t, want.Header, got.Header)
+			} else {
+				assert.Empty(t, got.Header)
+			}
+			assert.Equal(t, want.Cookie, got.Cookie)
+			assert.Equal(t, want.Auth, got.Auth)
+			assert.Equal(t, want.HTTPVersion, got.HTTPVersion)
+		})
+	}
+}
+
+func TestExportCurlScenarioWarnings(t *testing.T) {
+	command, warnings := ExportCurl(Config{
+		Func: "return 1",
+		Auth: &Auth{Type: AuthOAuth2, TokenURL: "http://foo.com/token", ClientID: "id", ClientSecret: "secret"},
+		Steps: []Step{
+			{Name: "login", Method: "POST", URL: "http://foo.com/login", Body: "user=ana", Header: map[string][]string{"Content-Type": {"application/x-www-form-urlencoded"}}},
+			{Method: "GET", URL: "http://foo.com/users/{{id}}"},
+		},
+	})
+	assert.Equal(t, `# login
+curl http://foo.com/login \
+  -H 'Content-Type: application/x-www-form-urlencoded' \
+  --data-raw user=ana
+
+# 2
+curl 'http://foo.com/users/{{id}}'`, command)
+	assert.Equal(t, []string{
+		"func is evaluated when the call runs, the body keeps its verb",
+		"oauth2 auth cannot be expressed with curl, requests are not authenticated",
+		"{{var}} variables are set while the scenario runs and are kept as is",
+	}, warnings)
+}
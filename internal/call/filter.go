@@ -0,0 +1,83 @@
+package call
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// filter keeps the entries selected by the Only, Tags and Exclude
+// options. An entry is kept when its name matches one of the Only
+// globs and it has one of the Tags, unless its name or one of its tags
+// matches an Exclude pattern
+func (o LoadOptions) filter(configs []Config) ([]Config, error) {
+	if len(o.Only) == 0 && len(o.Tags) == 0 && len(o.Exclude) == 0 {
+		return configs, nil
+	}
+	for _, pattern := range append(append([]string{}, o.Only...), o.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%w: %q", err, pattern)
+		}
+	}
+	var kept []Config
+	for _, c := range configs {
+		if o.selects(c) {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoMatchingCase, o.describeFilters())
+	}
+	return kept, nil
+}
+
+// selects tells whether the filters keep a Config
+func (o LoadOptions) selects(c Config) bool {
+	if len(o.Only) > 0 && !matchesAny(o.Only, c.Name) {
+		return false
+	}
+	if len(o.Tags) > 0 && !hasAnyTag(c, o.Tags) {
+		return false
+	}
+	if matchesAny(o.Exclude, c.Name) || hasAnyTag(c, o.Exclude) {
+		return false
+	}
+	return true
+}
+
+// describeFilters formats the filters for error messages
+func (o LoadOptions) describeFilters() string {
+	var filters []string
+	if len(o.Only) > 0 {
+		filters = append(filters, "only "+strings.Join(o.Only, ","))
+	}
+	if len(o.Tags) > 0 {
+		filters = append(filters, "tag "+strings.Join(o.Tags, ","))
+	}
+	if len(o.Exclude) > 0 {
+		filters = append(filters, "exclude "+strings.Join(o.Exclude, ","))
+	}
+	return strings.Join(filters, ", ")
+}
+
+// matchesAny tells whether name matches one of the globs
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyTag tells whether a Config has one of the tags
+func hasAnyTag(c Config, tags []string) bool {
+	for _, tag := range c.Tags {
+		for _, wanted := range tags {
+			if tag == wanted {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package call
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const taggedConfig = `[
+  {"name": "checkout-cart", "method": "GET", "url": "http://www.foo.com/cart", "tags": ["smoke"]},
+  {"name": "checkout-pay", "method": "POST", "url": "http://www.foo.com/pay", "tags": ["smoke", "slow"]},
+  {"name": "search", "method": "GET", "url": "http://www.foo.com/search", "tags": ["slow"]},
+  {"name": "home", "method": "GET", "url": "http://www.foo.com"}
+]`
+
+func TestLoadConfigWithFilters(t *testing.T) {
+	path := writeConfigFile(t, "config.json", taggedConfig)
+	tests := []struct {
+		name    string
+		options LoadOptions
+		want    []string
+	}{
+		{name: "no filters", options: LoadOptions{}, want: []string{"checkout-cart", "checkout-pay", "search", "home"}},
+		{name: "only glob", options: LoadOptions{Only: []string{"checkout*"}}, want: []string{"checkout-cart", "checkout-pay"}},
+		{name: "only names", options: LoadOptions{Only: []string{"home", "search"}}, want: []string{"search", "home"}},
+		{name: "tag", options: LoadOptions{Tags: []string{"smoke"}}, want: []string{"checkout-cart", "checkout-pay"}},
+		{name: "exclude tag", options: LoadOptions{Exclude: []string{"slow"}}, want: []string{"checkout-cart", "home"}},
+		{name: "exclude glob", options: LoadOptions{Exclude: []string{"checkout-*"}}, want: []string{"search", "home"}},
+		{name: "combined", options: LoadOptions{Only: []string{"checkout*"}, Exclude: []string{"slow"}}, want: []string{"checkout-cart"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotC, err := LoadConfigWithOptions(path, tt.options)
+			assert.Nil(t, err)
+			var names []string
+			for _, c := range gotC {
+				names = append(names, c.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestLoadConfigWithFiltersMatchingNothing(t *testing.T) {
+	path := writeConfigFile(t, "config.json", taggedConfig)
+	_, err := LoadConfigWithOptions(path, LoadOptions{Tags: []string{"nightly"}})
+	assert.True(t, errors.Is(err, ErrNoMatchingCase))
+	assert.Contains(t, err.Error(), "tag nightly")
+
+	_, err = LoadConfigWithOptions(path, LoadOptions{Only: []string{"[checkout"}})
+	assert.NotNil(t, err)
+}
+
+func TestConfigListRow(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   []string
+	}{
+		{
+			name:   "request with defaults",
+			config: Config{Name: "home", Method: "GET", URL: "http://www.foo.com", Tags: []string{"smoke", "fast"}},
+			want:   []string{"home", "GET", "http://www.foo.com", "10", "10", "smoke,fast"},
+		},
+		{
+			name:   "scenario",
+			config: Config{Name: "flow", Attempts: 5, ConcurrentAttempts: 2, Steps: []Step{{URL: "http://www.foo.com/login"}, {URL: "http://www.foo.com/cart"}}},
+			want:   []string{"flow", "2 STEPS", "http://www.foo.com/login", "5", "2", ""},
+		},
+		{
+			name:   "mix",
+			config: Config{Name: "shop", Mix: []MixEntry{{Step: Step{URL: "http://www.foo.com/search"}}}},
+			want:   []string{"shop", "MIX OF 1", "http://www.foo.com/search", "10", "10", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, configListRow(tt.config))
+		})
+	}
+}
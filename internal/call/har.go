@@ -0,0 +1,278 @@
+package call
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HAROptions selects the entries of a HAR file to import and how
+type HAROptions struct {
+	Domains      []string // hosts to keep, subdomains included, all when empty
+	ContentTypes []string // response content types to keep, such as json or text/html, all when empty
+	DropStatic   bool     // drop stylesheets, scripts, images, fonts and media
+	Scenario     bool     // a single scenario with a step per entry, instead of an entry per request
+}
+
+// harFile is the part of a HAR archive, as saved by browser DevTools,
+// describing the requests
+type harFile struct {
+	Log *struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	StartedDateTime string     `json:"startedDateTime"`
+	Time            float64    `json:"time"` // milliseconds the request took
+	Request         harRequest `json:"request"`
+	Response        struct {
+		Status  int `json:"status"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+		} `json:"content"`
+	} `json:"response"`
+	ResourceType string `json:"_resourceType"` // set by Chromium
+}
+
+type harRequest struct {
+	Method   string       `json:"method"`
+	URL      string       `json:"url"`
+	Headers  []harPair    `json:"headers"`
+	Cookies  []harPair    `json:"cookies"`
+	PostData *harPostData `json:"postData"`
+}
+
+type harPostData struct {
+	MimeType string    `json:"mimeType"`
+	Text     string    `json:"text"`
+	Params   []harPair `json:"params"`
+}
+
+type harPair struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// harSkippedHeaders are request headers net/http sets by itself
+var harSkippedHeaders = map[string]bool{"Host": true, "Content-Length": true, "Connection": true}
+
+// harStaticTypes are the Chromium resource types of static assets
+var harStaticTypes = map[string]bool{"stylesheet": true, "script": true, "image": true, "font": true, "media": true, "manifest": true}
+
+// harStaticExtensions are the URL path extensions of static assets
+var harStaticExtensions = map[string]bool{
+	".css": true, ".js": true, ".mjs": true, ".map": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".ico": true, ".webp": true, ".avif": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".mp4": true, ".webm": true, ".mp3": true, ".ogg": true,
+}
+
+// ImportHAR reads the requests of a HAR file, or of the standard input
+// for StdinPath, into Config entries, an entry per request. With the
+// Scenario option they become the steps of a single scenario instead,
+// the time between a response and the next request kept as the think
+// time of each step. Requests keep their method, headers, cookies and
+// body, and run once
+func ImportHAR(path string, options HAROptions) ([]Config, error) {
+	raw, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var har harFile
+	if err := json.Unmarshal(raw, &har); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHAR, err)
+	}
+	if har.Log == nil {
+		return nil, fmt.Errorf("%w: no log", ErrInvalidHAR)
+	}
+
+	var entries []harEntry
+	for _, entry := range har.Log.Entries {
+		if options.keep(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, ErrNoHAREntry
+	}
+	return harConfigs("HAR session", entries, options.Scenario), nil
+}
+
+// harConfigs converts HAR entries into Config entries, in the order
+// their requests started, or into a single scenario of the given name
+func harConfigs(name string, entries []harEntry, scenario bool) []Config {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].started().Before(entries[j].started())
+	})
+
+	if scenario {
+		steps := make([]Step, len(entries))
+		for i, entry := range entries {
+			step, _ := entry.step(true)
+			if i < len(entries)-1 {
+				step.ThinkTime = entry.gap(entries[i+1])
+			}
+			steps[i] = step
+		}
+		return []Config{{Name: name, Attempts: 1, ConcurrentAttempts: 1, Steps: steps}}
+	}
+	configs := make([]Config, len(entries))
+	for i, entry := range entries {
+		step, cookie := entry.step(false)
+		configs[i] = Config{
+			Name:               step.Name,
+			Method:             step.Method,
+			URL:                step.URL,
+			Body:               step.Body,
+			Header:             step.Header,
+			Cookie:             cookie,
+			Attempts:           1,
+			ConcurrentAttempts: 1,
+		}
+	}
+	return configs
+}
+
+// keep tells whether the options import a HAR entry. Only HTTP
+// requests with a method call-it sends are imported
+func (o HAROptions) keep(entry harEntry) bool {
+	u, err := url.Parse(entry.Request.URL)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" || !isMethodAllowed(strings.ToUpper(entry.Request.Method)) {
+		return false
+	}
+	if len(o.Domains) > 0 && !matchesDomain(u.Hostname(), o.Domains) {
+		return false
+	}
+	contentType := strings.ToLower(entry.Response.Content.MimeType)
+	if len(o.ContentTypes) > 0 && !containsAny(contentType, o.ContentTypes) {
+		return false
+	}
+	return !o.DropStatic || !entry.static(u, contentType)
+}
+
+// static tells whether a HAR entry loads a static asset, from its
+// resource type, its response content type or its URL extension
+func (e harEntry) static(u *url.URL, contentType string) bool {
+	if harStaticTypes[e.ResourceType] {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "text/css", strings.HasSuffix(mediaType, "javascript"),
+		strings.HasPrefix(mediaType, "image/"), strings.HasPrefix(mediaType, "font/"),
+		strings.HasPrefix(mediaType, "audio/"), strings.HasPrefix(mediaType, "video/"):
+		return true
+	}
+	return harStaticExtensions[strings.ToLower(path.Ext(u.Path))]
+}
+
+// step converts a HAR entry into a request. Cookies are returned apart
+// unless inHeader, which keeps them as a Cookie header
+func (e harEntry) step(inHeader bool) (step Step, cookie string) {
+	request := e.Request
+	step = Step{Method: strings.ToUpper(request.Method), URL: request.URL}
+	if u, err := url.Parse(request.URL); err == nil {
+		step.Name = step.Method + " " + u.EscapedPath()
+	}
+
+	header := make(http.Header)
+	for _, pair := range request.Headers {
+		name := http.CanonicalHeaderKey(pair.Name)
+		switch {
+		case strings.HasPrefix(pair.Name, ":"), harSkippedHeaders[name]:
+		case name == "Cookie" && !inHeader:
+			cookie = joinCookies(cookie, pair.Value)
+		default:
+			header.Add(name, pair.Value)
+		}
+	}
+	if header.Get("Cookie") == "" && cookie == "" && len(request.Cookies) > 0 {
+		pairs := make([]string, len(request.Cookies))
+		for i, c := range request.Cookies {
+			pairs[i] = c.Name + "=" + c.Value
+		}
+		if inHeader {
+			header.Set("Cookie", strings.Join(pairs, "; "))
+		} else {
+			cookie = strings.Join(pairs, "; ")
+		}
+	}
+	if len(header) > 0 {
+		step.Header = header
+	}
+
+	if data := request.PostData; data != nil {
+		step.Body = data.Text
+		if step.Body == "" && len(data.Params) > 0 {
+			values := url.Values{}
+			for _, param := range data.Params {
+				values.Add(param.Name, param.Value)
+			}
+			step.Body = values.Encode()
+		}
+		if data.MimeType != "" && header.Get("Content-Type") == "" {
+			header.Set("Content-Type", data.MimeType)
+			step.Header = header
+		}
+	}
+	return
+}
+
+// started returns when the request of a HAR entry started, the zero
+// time when unknown
+func (e harEntry) started() time.Time {
+	started, _ := time.Parse(time.RFC3339Nano, e.StartedDateTime)
+	return started
+}
+
+// gap returns the fixed think time between the response of a HAR entry
+// and the next request, nil when they overlap or times are unknown
+func (e harEntry) gap(next harEntry) *ThinkTime {
+	if e.started().IsZero() || next.started().IsZero() {
+		return nil
+	}
+	ended := e.started().Add(time.Duration(e.Time * float64(time.Millisecond)))
+	seconds := next.started().Sub(ended).Seconds()
+	if seconds <= 0 {
+		return nil
+	}
+	return &ThinkTime{Distribution: DistributionFixed, Value: math.Round(seconds*1000) / 1000}
+}
+
+// matchesDomain tells whether host is one of domains or a subdomain of one
+func matchesDomain(host string, domains []string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny tells whether s contains any of the substrings, ignoring case
+func containsAny(s string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, strings.ToLower(substring)) {
+			return true
+		}
+	}
+	return false
+}
+
+// joinCookies appends cookie pairs to a Cookie header value
+func joinCookies(cookie, pairs string) string {
+	if cookie == "" {
+		return pairs
+	}
+	return cookie + "; " + pairs
+}
@@ -0,0 +1,158 @@
+package call
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testHAR = `{
+  "log": {
+    "version": "1.2",
+    "entries": [
+      {
+        "startedDateTime": "2025-03-01T10:00:02.000Z",
+        "time": 250,
+        "request": {
+          "method": "POST",
+          "url": "https://api.shop.com/cart?item=1",
+          "headers": [
+            {"name": ":authority", "value": "api.shop.com"},
+            {"name": "content-type", "value": "application/json"},
+            {"name": "content-length", "value": "12"},
+            {"name": "cookie", "value": "sid=abc; theme=dark"}
+          ],
+          "cookies": [{"name": "sid", "value": "abc"}, {"name": "theme", "value": "dark"}],
+          "postData": {"mimeType": "application/json", "text": "{\"qty\": 2}"}
+        },
+        "response": {"content": {"mimeType": "application/json; charset=utf-8"}}
+      },
+      {
+        "startedDateTime": "2025-03-01T10:00:00.000Z",
+        "time": 500,
+        "request": {
+          "method": "GET",
+          "url": "https://www.shop.com/",
+          "headers": [{"name": "Accept", "value": "text/html"}, {"name": "Host", "value": "www.shop.com"}],
+          "cookies": [{"name": "sid", "value": "abc"}]
+        },
+        "response": {"content": {"mimeType": "text/html"}}
+      },
+      {
+        "startedDateTime": "2025-03-01T10:00:00.600Z",
+        "time": 20,
+        "request": {"method": "GET", "url": "https://cdn.shop.com/app.js?v=3", "headers": []},
+        "response": {"content": {"mimeType": "application/javascript"}},
+        "_resourceType": "script"
+      },
+      {
+        "startedDateTime": "2025-03-01T10:00:00.700Z",
+        "time": 20,
+        "request": {"method": "GET", "url": "https://www.shop.com/logo", "headers": []},
+        "response": {"content": {"mimeType": "image/png"}}
+      },
+      {
+        "startedDateTime": "2025-03-01T10:00:03.000Z",
+        "time": 100,
+        "request": {
+          "method": "POST",
+          "url": "https://www.tracker.com/collect",
+          "headers": [],
+          "postData": {"mimeType": "application/x-www-form-urlencoded", "params": [{"name": "e", "value": "view"}, {"name": "p", "value": "/cart"}]}
+        },
+        "response": {"content": {"mimeType": "text/plain"}}
+      },
+      {
+        "startedDateTime": "2025-03-01T10:00:04.000Z",
+        "time": 10,
+        "request": {"method": "GET", "url": "wss://api.shop.com/live", "headers": []},
+        "response": {"content": {"mimeType": ""}}
+      }
+    ]
+  }
+}`
+
+func TestImportHAR(t *testing.T) {
+	path := writeConfigFile(t, "session.har", testHAR)
+
+	configs, err := ImportHAR(path, HAROptions{})
+	assert.Nil(t, err)
+	assert.Len(t, configs, 5)
+	names := make([]string, len(configs))
+	for i, c := range configs {
+		names[i] = c.Name
+		assert.Nil(t, c.CheckDefaults(), c.Name)
+	}
+	assert.Equal(t, []string{"GET /", "GET /app.js", "GET /logo", "POST /cart", "POST /collect"}, names)
+
+	assert.Equal(t, Config{
+		Name: "GET /", Method: "GET", URL: "https://www.shop.com/",
+		Header: map[string][]string{"Accept": {"text/html"}}, Cookie: "sid=abc",
+		Attempts: 1, ConcurrentAttempts: 1,
+	}, configs[0])
+	assert.Equal(t, Config{
+		Name: "POST /cart", Method: "POST", URL: "https://api.shop.com/cart?item=1", Body: `{"qty": 2}`,
+		Header: map[string][]string{"Content-Type": {"application/json"}}, Cookie: "sid=abc; theme=dark",
+		Attempts: 1, ConcurrentAttempts: 1,
+	}, configs[3])
+	assert.Equal(t, "e=view&p=%2Fcart", configs[4].Body)
+	assert.Equal(t, []string{"application/x-www-form-urlencoded"}, configs[4].Header["Content-Type"])
+}
+
+func TestImportHARFilters(t *testing.T) {
+	path := writeConfigFile(t, "session.har", testHAR)
+
+	tests := []struct {
+		name    string
+		options HAROptions
+		want    []string
+	}{
+		{name: "domain", options: HAROptions{Domains: []string{"shop.com"}}, want: []string{"GET /", "GET /app.js", "GET /logo", "POST /cart"}},
+		{name: "subdomain", options: HAROptions{Domains: []string{"API.shop.com", "tracker.com"}}, want: []string{"POST /cart", "POST /collect"}},
+		{name: "content type", options: HAROptions{ContentTypes: []string{"json", "text/html"}}, want: []string{"GET /", "POST /cart"}},
+		{name: "static", options: HAROptions{DropStatic: true}, want: []string{"GET /", "POST /cart", "POST /collect"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configs, err := ImportHAR(path, tt.options)
+			assert.Nil(t, err)
+			var names []string
+			for _, c := range configs {
+				names = append(names, c.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+
+	_, err := ImportHAR(path, HAROptions{Domains: []string{"other.com"}})
+	assert.True(t, errors.Is(err, ErrNoHAREntry))
+}
+
+func TestImportHARScenario(t *testing.T) {
+	path := writeConfigFile(t, "session.har", testHAR)
+
+	configs, err := ImportHAR(path, HAROptions{Scenario: true, DropStatic: true, Domains: []string{"shop.com"}})
+	assert.Nil(t, err)
+	assert.Len(t, configs, 1)
+	scenario := configs[0]
+	assert.Nil(t, scenario.CheckDefaults())
+	assert.Len(t, scenario.Steps, 2)
+
+	// the page took 0.5s, the cart was posted 1.5s after it loaded
+	home, cart := scenario.Steps[0], scenario.Steps[1]
+	assert.Equal(t, &ThinkTime{Distribution: DistributionFixed, Value: 1.5}, home.ThinkTime)
+	assert.Equal(t, []string{"sid=abc"}, home.Header["Cookie"])
+	assert.Nil(t, cart.ThinkTime)
+	assert.Equal(t, []string{"sid=abc; theme=dark"}, cart.Header["Cookie"])
+	assert.Equal(t, `{"qty": 2}`, cart.Body)
+}
+
+func TestImportHARErrors(t *testing.T) {
+	_, err := ImportHAR(writeConfigFile(t, "bad.har", `{"log": `), HAROptions{})
+	assert.True(t, errors.Is(err, ErrInvalidHAR))
+	_, err = ImportHAR(writeConfigFile(t, "bad.har", `{"entries": []}`), HAROptions{})
+	assert.True(t, errors.Is(err, ErrInvalidHAR))
+	_, err = ImportHAR(writeConfigFile(t, "empty.har", `{"log": {"entries": []}}`), HAROptions{})
+	assert.True(t, errors.Is(err, ErrNoHAREntry))
+}
@@ -0,0 +1,300 @@
+package call
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pedrolopesme/call-it/internal/version"
+)
+
+// HistoryDirEnv names the environment variable overriding the
+// directory of the run history
+const HistoryDirEnv = "CALL_IT_HISTORY"
+
+// Size of the trend charts of a case: the runs drawn, oldest first,
+// and the rows of each chart
+const (
+	HistoryTrendRuns   = 60
+	HistoryTrendHeight = 10
+)
+
+// A Run is a Result kept in the history, along with what produced it
+type Run struct {
+	ID      string          `json:"id"`
+	Case    string          `json:"case"` // name of the case, or its URL when unnamed
+	Time    time.Time       `json:"time"`
+	Commit  string          `json:"commit,omitempty"` // git commit of the directory call-it ran in
+	Version version.Info    `json:"version"`
+	Config  json.RawMessage `json:"config"` // secrets masked
+	Result  ResultReport    `json:"result"`
+}
+
+// A History stores runs in a directory, a JSON file per run
+type History struct {
+	dir string
+}
+
+// sensitiveHeaders name the headers whose values are masked in the
+// configs of the history
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"X-Api-Key":           true,
+	"X-Auth-Token":        true,
+}
+
+// gitCommit returns the git commit checked out in the working
+// directory, empty outside of a git repository
+var gitCommit = defaultGitCommit
+
+func defaultGitCommit() string {
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// DefaultHistoryDir returns the directory of the run history: the one
+// HistoryDirEnv names, or a call-it/history directory under the user
+// data directory, XDG_DATA_HOME or ~/.local/share on Unix
+func DefaultHistoryDir() (string, error) {
+	if dir := os.Getenv(HistoryDirEnv); dir != "" {
+		return dir, nil
+	}
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		switch runtime.GOOS {
+		case "windows":
+			data = os.Getenv("LocalAppData")
+		case "darwin":
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			data = filepath.Join(home, "Library", "Application Support")
+		}
+	}
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "call-it", "history"), nil
+}
+
+// OpenHistory opens the run history stored in dir, DefaultHistoryDir
+// when empty, creating the directory if needed
+func OpenHistory(dir string) (*History, error) {
+	if dir == "" {
+		var err error
+		if dir, err = DefaultHistoryDir(); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &History{dir: dir}, nil
+}
+
+// Save stores the result of a run of config, stamped with the current
+// time, the git commit of the working directory and the call-it version
+func (h *History) Save(config Config, result Result) (Run, error) {
+	registerAuthSecrets(config.Auth)
+	raw, err := json.Marshal(maskConfig(config))
+	if err != nil {
+		return Run{}, err
+	}
+	masked := MaskSecrets(string(raw))
+	if !json.Valid([]byte(masked)) {
+		return Run{}, fmt.Errorf("%w: masking secrets broke the config", ErrInvalidRun)
+	}
+
+	report := NewResultReport(result)
+	run := Run{
+		Case:    report.Name,
+		Time:    time.Now().UTC(),
+		Commit:  gitCommit(),
+		Version: version.Get(),
+		Config:  json.RawMessage(masked),
+		Result:  report,
+	}
+	if run.Case == "" {
+		run.Case = report.URL
+	}
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return Run{}, err
+	}
+	run.ID = run.Time.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+
+	content, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return Run{}, err
+	}
+	// written aside and renamed, so runs are never read half written
+	temporary := filepath.Join(h.dir, "."+run.ID+".json")
+	if err := os.WriteFile(temporary, content, 0o600); err != nil {
+		return Run{}, err
+	}
+	return run, os.Rename(temporary, filepath.Join(h.dir, run.ID+".json"))
+}
+
+// List returns the runs of a case, or of every case when name is
+// empty, oldest first. Files that cannot be read are skipped and
+// reported by warnings
+func (h *History) List(name string) (runs []Run, warnings []string, err error) {
+	entries, err := os.ReadDir(h.dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		run, err := h.read(entry.Name())
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("run skipped: %v", err))
+			continue
+		}
+		if name == "" || run.Case == name {
+			runs = append(runs, run)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].Time.Before(runs[j].Time)
+	})
+	return runs, warnings, nil
+}
+
+// Get returns the run of an ID
+func (h *History) Get(id string) (Run, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return Run{}, fmt.Errorf("%w: %q", ErrUnknownRun, id)
+	}
+	run, err := h.read(id + ".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		return Run{}, fmt.Errorf("%w: %q", ErrUnknownRun, id)
+	}
+	return run, err
+}
+
+// read reads the run stored in a file of the history
+func (h *History) read(file string) (run Run, err error) {
+	raw, err := os.ReadFile(filepath.Join(h.dir, file))
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(raw, &run); err != nil {
+		err = fmt.Errorf("%w: %s: %v", ErrInvalidRun, file, err)
+	}
+	return
+}
+
+// maskConfig returns config with its auth secrets, the values of its
+// sensitive headers and its cookie masked, in its steps and mix entries
+// too. Masking fields rather than their JSON catches secrets the JSON
+// escapes or too short to be registered
+func maskConfig(config Config) Config {
+	if config.Auth != nil {
+		auth := *config.Auth
+		for _, secret := range []*string{&auth.Password, &auth.Token, &auth.ClientSecret, &auth.Secret, &auth.SessionToken} {
+			if *secret != "" {
+				*secret = SecretMask
+			}
+		}
+		config.Auth = &auth
+	}
+	config.Header = maskHeader(config.Header)
+	if config.Cookie != "" {
+		config.Cookie = SecretMask
+	}
+	config.Steps = append([]Step(nil), config.Steps...)
+	for i := range config.Steps {
+		config.Steps[i].Header = maskHeader(config.Steps[i].Header)
+	}
+	config.Mix = append([]MixEntry(nil), config.Mix...)
+	for i := range config.Mix {
+		config.Mix[i].Header = maskHeader(config.Mix[i].Header)
+	}
+	return config
+}
+
+// maskHeader returns a copy of header with the values of the
+// sensitive headers masked
+func maskHeader(header map[string][]string) map[string][]string {
+	if header == nil {
+		return nil
+	}
+	masked := make(map[string][]string, len(header))
+	for key, values := range header {
+		masked[key] = values
+		if sensitiveHeaders[http.CanonicalHeaderKey(key)] {
+			masked[key] = make([]string, len(values))
+			for i := range values {
+				masked[key][i] = SecretMask
+			}
+		}
+	}
+	return masked
+}
+
+// trendChart draws values as an ASCII chart of height rows, a column
+// per value, the highest value on the top row and the lowest on the
+// bottom one, labeled with format
+func trendChart(values []float64, height int, format func(float64) string) string {
+	if len(values) == 0 || height < 2 {
+		return ""
+	}
+	low, high := values[0], values[0]
+	for _, value := range values {
+		low, high = min(low, value), max(high, value)
+	}
+	rows := make([][]byte, height)
+	for i := range rows {
+		rows[i] = []byte(strings.Repeat(" ", len(values)))
+	}
+	for column, value := range values {
+		row := 0
+		if high > low {
+			row = int((value-low)/(high-low)*float64(height-1) + 0.5)
+		}
+		rows[height-1-row][column] = '*'
+	}
+
+	top, bottom := format(high), format(low)
+	width := max(len(top), len(bottom))
+	var b strings.Builder
+	for i, row := range rows {
+		label := ""
+		switch i {
+		case 0:
+			label = top
+		case height - 1:
+			label = bottom
+		}
+		axis := "│"
+		if label != "" {
+			axis = "┤"
+		}
+		fmt.Fprintf(&b, "%*s %s%s\n", width, label, axis, strings.TrimRight(string(row), " "))
+	}
+	fmt.Fprintf(&b, "%*s └%s\n", width, "", strings.Repeat("─", len(values)))
+	return b.String()
+}
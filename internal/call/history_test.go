@@ -0,0 +1,150 @@
+package call
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedrolopesme/call-it/internal/version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultHistoryDir(t *testing.T) {
+	t.Setenv(HistoryDirEnv, "/tmp/runs")
+	dir, err := DefaultHistoryDir()
+	assert.Nil(t, err)
+	assert.Equal(t, "/tmp/runs", dir)
+
+	t.Setenv(HistoryDirEnv, "")
+	t.Setenv("XDG_DATA_HOME", "/data")
+	dir, err = DefaultHistoryDir()
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join("/data", "call-it", "history"), dir)
+}
+
+func TestHistory(t *testing.T) {
+	gitCommit = func() string { return "0123456789abcdef" }
+	defer func() { gitCommit = defaultGitCommit }()
+	history, err := OpenHistory(filepath.Join(t.TempDir(), "history"))
+	assert.Nil(t, err)
+
+	target, _ := url.Parse("http://localhost/home")
+	save := func(name string, execution float64) Run {
+		result := Result{URL: target, name: name, status: make(map[int]StatusCodeBenchmark), totalExecution: 1}
+		result.record(200, execution)
+		config := Config{Name: name, URL: target.String(), Auth: &Auth{Type: AuthBearer, Token: "t0ken-in-history"}}
+		run, err := history.Save(config, result)
+		assert.Nil(t, err)
+		return run
+	}
+	first := save("home", 0.1)
+	second := save("login", 0.2)
+	third := save("home", 0.3)
+
+	assert.Equal(t, "home", first.Case)
+	assert.Equal(t, "0123456789abcdef", first.Commit)
+	assert.Equal(t, version.Get(), first.Version)
+	assert.NotEqual(t, first.ID, third.ID)
+	assert.Contains(t, string(first.Config), `"name":"home"`)
+	assert.NotContains(t, string(first.Config), "t0ken-in-history")
+
+	runs, _, err := history.List("")
+	assert.Nil(t, err)
+	assert.Len(t, runs, 3)
+	runs, _, err = history.List("home")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{first.ID, third.ID}, []string{runs[0].ID, runs[1].ID})
+	assert.Equal(t, 0.3, runs[1].Result.Percentiles.P95)
+
+	run, err := history.Get(second.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "login", run.Case)
+	assert.True(t, run.Time.Equal(second.Time))
+	for _, id := range []string{"missing", "", "../history/" + second.ID, "." + second.ID} {
+		_, err = history.Get(id)
+		assert.True(t, errors.Is(err, ErrUnknownRun), id)
+	}
+
+	assert.Nil(t, os.WriteFile(filepath.Join(history.dir, "broken.json"), []byte("{"), 0o600))
+	runs, warnings, err := history.List("")
+	assert.Nil(t, err)
+	assert.Len(t, runs, 3)
+	assert.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "broken.json")
+}
+
+func TestHistoryMasksCredentials(t *testing.T) {
+	history, err := OpenHistory(t.TempDir())
+	assert.Nil(t, err)
+	t.Setenv("CALL_IT_HISTORY_TOKEN", "env-t0ken-in-history")
+	stdin = strings.NewReader(`[{
+		"name": "masked",
+		"method": "GET",
+		"url": "http://localhost/",
+		"cookie": "sid=cookie-in-history",
+		"header": {"authorization": ["Bearer pasted-t0ken"], "X-Trace": ["${CALL_IT_HISTORY_TOKEN}"], "Accept": ["application/json"]},
+		"steps": [{"url": "http://localhost/", "header": {"Cookie": ["sid=step-cookie"]}}]
+	}]`)
+	defer func() { stdin = os.Stdin }()
+	configs, err := LoadConfig(StdinPath)
+	assert.Nil(t, err)
+
+	target, _ := url.Parse("http://localhost/")
+	run, err := history.Save(configs[0], Result{URL: target, name: "masked", status: make(map[int]StatusCodeBenchmark)})
+	assert.Nil(t, err)
+	for _, secret := range []string{"cookie-in-history", "pasted-t0ken", "env-t0ken-in-history", "step-cookie"} {
+		assert.NotContains(t, string(run.Config), secret)
+	}
+	assert.Contains(t, string(run.Config), `"Accept":["application/json"]`)
+	assert.Equal(t, "sid=cookie-in-history", configs[0].Cookie)
+	assert.Equal(t, "Bearer pasted-t0ken", configs[0].Header["authorization"][0])
+}
+
+func TestHistoryMasksAuthSecrets(t *testing.T) {
+	history, err := OpenHistory(t.TempDir())
+	assert.Nil(t, err)
+	target, _ := url.Parse("http://localhost/")
+	result := Result{URL: target, status: make(map[int]StatusCodeBenchmark)}
+
+	auth := &Auth{Type: AuthBasic, Username: "ana", Password: "p&ss<w>rd"}
+	run, err := history.Save(Config{URL: target.String(), Auth: auth}, result)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(run.Config), `p\u0026ss\u003cw\u003erd`)
+	assert.Contains(t, string(run.Config), `"username":"ana","password":"****"`)
+	assert.Equal(t, "p&ss<w>rd", auth.Password)
+
+	run, err = history.Save(Config{URL: target.String(), Auth: &Auth{Type: AuthBearer, Token: "abc"}}, result)
+	assert.Nil(t, err)
+	assert.Contains(t, string(run.Config), `"token":"****"`)
+}
+
+func TestHistoryUnnamedCase(t *testing.T) {
+	history, err := OpenHistory(t.TempDir())
+	assert.Nil(t, err)
+	target, _ := url.Parse("http://localhost/")
+	run, err := history.Save(Config{URL: target.String()}, Result{URL: target, status: make(map[int]StatusCodeBenchmark)})
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost/", run.Case)
+	assert.True(t, time.Since(run.Time) < time.Minute)
+}
+
+func TestTrendChart(t *testing.T) {
+	chart := trendChart([]float64{1, 2, 3, 2, 5}, 5, formatTime)
+	assert.Equal(t, strings.Join([]string{
+		"5.00s ┤    *",
+		"      │",
+		"      │  *",
+		"      │ * *",
+		"1.00s ┤*",
+		"      └─────",
+		"",
+	}, "\n"), chart)
+
+	flat := trendChart([]float64{2, 2}, 3, formatRate)
+	assert.Equal(t, "2.00/s ┤\n       │\n2.00/s ┤**\n       └──\n", flat)
+	assert.Equal(t, "", trendChart(nil, 5, formatTime))
+}
@@ -0,0 +1,136 @@
+package call
+
+import (
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A MixEntry is one request definition of a weighted traffic mix. All
+// entries of a Config share its workers, each request picking an entry
+// with probability proportional to its weight
+type MixEntry struct {
+	Step
+	Weight int `json:"weight,omitempty"`
+}
+
+// endpointResponse is the outcome of a single mix request
+type endpointResponse struct {
+	HTTPResponse
+	endpoint int
+}
+
+// checkMix validates every entry of a traffic mix. Entries without
+// weight default to 1
+func checkMix(mix []MixEntry) (errs []fieldError) {
+	steps := make([]Step, len(mix))
+	for i := range mix {
+		if mix[i].Weight < 0 {
+			errs = append(errs, fieldError{strconv.Itoa(i) + ".weight", ErrInvalidWeight})
+		}
+		if mix[i].Weight == 0 {
+			mix[i].Weight = 1
+		}
+		steps[i] = mix[i].Step
+	}
+	errs = append(errs, checkSteps(steps)...)
+	for i := range mix {
+		mix[i].Method = steps[i].Method
+	}
+	return
+}
+
+// makeMix spreads Attempts requests among the mix entries using one
+// worker per client, feeding result with the aggregated responses and
+// one Result per endpoint. Config checks apply to every endpoint, and
+// the aggregated result reports them along with the checks of each entry
+func (call *ConcurrentCall) makeMix(result *Result, clients []*http.Client) {
+	mix := append([]MixEntry{}, call.config.Mix...)
+	result.endpoints = make([]Result, len(mix))
+	// positions maps the checks of every endpoint to the aggregated ones
+	positions := make([][]int, len(mix))
+	cumulative := make([]int, len(mix))
+	total := 0
+	for i := range mix {
+		for j := range call.config.Checks {
+			positions[i] = append(positions[i], j)
+		}
+		entry := mix[i].Name
+		if entry == "" {
+			entry = mix[i].URL
+		}
+		for _, check := range mix[i].Checks {
+			positions[i] = append(positions[i], len(result.checks))
+			result.checks = append(result.checks, CheckBenchmark{name: entry + ": " + check.label()})
+		}
+		mix[i].Checks = append(append([]Check{}, call.config.Checks...), mix[i].Checks...)
+		endpointURL, _ := url.Parse(mix[i].URL)
+		result.endpoints[i] = Result{
+			URL:    endpointURL,
+			name:   mix[i].Name,
+			status: make(map[int]StatusCodeBenchmark),
+			checks: newCheckBenchmarks(mix[i].Checks),
+		}
+		total += mix[i].Weight
+		cumulative[i] = total
+	}
+
+	requests := make(chan int, call.Attempts)
+	for i := 0; i < call.Attempts; i++ {
+		requests <- pickEndpoint(cumulative, rand.Intn(total))
+	}
+	close(requests)
+
+	responses := make(chan endpointResponse)
+	var wg sync.WaitGroup
+	wg.Add(len(clients))
+	for _, client := range clients {
+		go func() {
+			defer wg.Done()
+			vars := make(map[string]string)
+			for endpoint := range requests {
+				beginning := time.Now()
+				response, _ := runStep(client, mix[endpoint].Step, vars)
+				responses <- endpointResponse{HTTPResponse: response, endpoint: endpoint}
+				think(mix[endpoint].thinkTime(call.config.ThinkTime))
+				pace(beginning, call.config.Pacing)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(responses)
+	}()
+
+	for response := range responses {
+		result.record(response.status, response.execution)
+		result.endpoints[response.endpoint].record(response.status, response.execution)
+		result.endpoints[response.endpoint].recordChecks(response.checks)
+		for k, passed := range response.checks {
+			check := &result.checks[positions[response.endpoint][k]]
+			if passed {
+				check.passed++
+			} else {
+				check.failed++
+			}
+		}
+	}
+	for i := range result.endpoints {
+		result.endpoints[i].summarize()
+	}
+	call.Attempts = 0
+}
+
+// pickEndpoint returns the index of the entry whose cumulative weight
+// range holds n
+func pickEndpoint(cumulative []int, n int) int {
+	for i, weight := range cumulative {
+		if n < weight {
+			return i
+		}
+	}
+	return len(cumulative) - 1
+}
@@ -0,0 +1,94 @@
+package call
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeMixSharesAttemptsByWeight(test *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "http://www.foo.com/search",
+		httpmock.NewStringResponder(200, `[]`))
+	httpmock.RegisterResponder("POST", "http://www.foo.com/cart",
+		httpmock.NewStringResponder(201, `{}`))
+
+	config := Config{
+		Name: "shop",
+		Mix: []MixEntry{
+			{Step: Step{Name: "search", URL: "http://www.foo.com/search"}, Weight: 9},
+			{Step: Step{Name: "cart", Method: http.MethodPost, URL: "http://www.foo.com/cart", Checks: []Check{{Type: CheckBodyContains, Expr: "{}"}}}, Weight: 1},
+		},
+		Checks: []Check{{Type: CheckStatus, Status: []int{200}}},
+	}
+	assert.Nil(test, config.CheckDefaults())
+	call := ConcurrentCall{Attempts: 1000, ConcurrentAttempts: 10}
+	call.URL, _ = url.Parse(config.Mix[0].URL)
+	call.SetConfig(config)
+	result := call.MakeIt()
+
+	endpoints := result.GetEndpoints()
+	assert.Equal(test, 2, len(endpoints))
+	assert.Equal(test, 1000, result.status[200].total+result.status[201].total)
+	assert.Equal(test, result.status[200].total, endpoints[0].status[200].total)
+	assert.Equal(test, result.status[201].total, endpoints[1].status[201].total)
+	assert.InDelta(test, 900, endpoints[0].status[200].total, 100)
+	assert.Equal(test, endpoints[1].status[201].total, endpoints[1].checks[0].failed)
+	assert.Equal(test, 0, len(config.Mix[0].Checks))
+
+	checks := result.GetChecks()
+	assert.Equal(test, 2, len(checks))
+	assert.Equal(test, result.status[200].total, checks[0].passed)
+	assert.Equal(test, result.status[201].total, checks[0].failed)
+	assert.Equal(test, "cart: body_contains {}", checks[1].GetName())
+	assert.Equal(test, result.status[201].total, checks[1].passed)
+}
+
+func TestMakeMixExtractsVariables(test *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "http://www.foo.com/token",
+		httpmock.NewStringResponder(200, `{"token": "abc"}`))
+	httpmock.RegisterResponder("GET", "http://www.foo.com/search",
+		httpmock.NewStringResponder(200, `[]`))
+
+	config := Config{
+		Name: "shop",
+		Mix: []MixEntry{
+			{Step: Step{URL: "http://www.foo.com/token", Extract: []Extraction{{Var: "token", From: ExtractFromJSON, Expr: "token"}}}},
+			{Step: Step{URL: "http://www.foo.com/search", Extract: []Extraction{{Var: "id", From: ExtractFromRegex, Expr: `(\d+)`}}}},
+		},
+	}
+	assert.Nil(test, config.CheckDefaults())
+	call := ConcurrentCall{Attempts: 100, ConcurrentAttempts: 5}
+	call.URL, _ = url.Parse(config.Mix[0].URL)
+	call.SetConfig(config)
+	result := call.MakeIt()
+
+	assert.Equal(test, 100, result.status[200].total)
+}
+
+func TestPickEndpoint(test *testing.T) {
+	cumulative := []int{70, 95, 100}
+	assert.Equal(test, 0, pickEndpoint(cumulative, 0))
+	assert.Equal(test, 0, pickEndpoint(cumulative, 69))
+	assert.Equal(test, 1, pickEndpoint(cumulative, 70))
+	assert.Equal(test, 2, pickEndpoint(cumulative, 99))
+}
+
+func TestCheckMix(test *testing.T) {
+	mix := []MixEntry{{Step: Step{URL: "http://www.foo.com"}}}
+	assert.Nil(test, checkMix(mix))
+	assert.Equal(test, 1, mix[0].Weight)
+	assert.Equal(test, http.MethodGet, mix[0].Method)
+
+	assert.NotNil(test, checkMix([]MixEntry{{Step: Step{URL: "http://www.foo.com"}, Weight: -1}}))
+	assert.NotNil(test, checkMix([]MixEntry{{Step: Step{Method: "ASHE", URL: "http://www.foo.com"}}}))
+
+	config := Config{Name: "both", Steps: []Step{{URL: "http://www.foo.com"}}, Mix: mix}
+	assert.Equal(test, ErrStepsWithMix, config.CheckDefaults())
+}
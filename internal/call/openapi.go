@@ -0,0 +1,575 @@
+package call
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPIOptions tune how an OpenAPI specification is imported
+type OpenAPIOptions struct {
+	BaseURL string // URL the paths are relative to, the first server of the specification when empty
+}
+
+// openAPIMethods are the operation keys of a path item, in the order
+// operations are imported
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// maxRefDepth bounds the chains of references, so circular ones end
+const maxRefDepth = 8
+
+var pathParamPattern = regexp.MustCompile(`{([^}]+)}`)
+
+// openAPISpec is an OpenAPI document decoded as maps, slices and scalars
+type openAPISpec struct {
+	root     map[string]interface{}
+	warnings []string
+}
+
+// ImportOpenAPI generates a Config entry per operation of an OpenAPI 3
+// specification, JSON or YAML, read from path or from the standard
+// input for StdinPath. Parameters and request bodies take the values of
+// their examples, or else values synthesized from their schemas, and
+// operations are tagged with their tags. Credentials of the security
+// schemes are ${VAR} references, named after the scheme, to be set when
+// the config runs. What cannot be imported is reported by warnings
+func ImportOpenAPI(path string, options OpenAPIOptions) (configs []Config, warnings []string, err error) {
+	raw, err := readConfigFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	format, err := detectFormat(path, raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := decodeDocument(raw, format)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidOpenAPI, err)
+	}
+	root, _ := doc.(map[string]interface{})
+	if version, _ := root["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, nil, fmt.Errorf("%w: only OpenAPI 3 specifications are supported", ErrInvalidOpenAPI)
+	}
+	spec := &openAPISpec{root: root}
+
+	baseURL := options.BaseURL
+	if baseURL == "" {
+		baseURL = spec.serverURL()
+	}
+	if parsed, err := url.Parse(baseURL); err != nil || !parsed.IsAbs() {
+		return nil, nil, fmt.Errorf("%w: %q", ErrNoBaseURL, baseURL)
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	paths, _ := root["paths"].(map[string]interface{})
+	names := make([]string, 0, len(paths))
+	for name := range paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		item := spec.resolve(paths[name])
+		for _, method := range openAPIMethods {
+			if operation := spec.resolve(item[method]); operation != nil {
+				configs = append(configs, spec.operation(baseURL, name, strings.ToUpper(method), item, operation))
+			}
+		}
+	}
+	if len(configs) == 0 {
+		return nil, spec.warnings, fmt.Errorf("%w: no operation", ErrInvalidOpenAPI)
+	}
+	return configs, spec.warnings, nil
+}
+
+// warn records a problem with an operation
+func (s *openAPISpec) warn(operation, format string, args ...interface{}) {
+	s.warnings = append(s.warnings, operation+": "+fmt.Sprintf(format, args...))
+}
+
+// resolve follows the local $ref of a node, returning nil for nodes
+// that are not objects and references it cannot follow
+func (s *openAPISpec) resolve(node interface{}) map[string]interface{} {
+	for i := 0; i < maxRefDepth; i++ {
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		ref, ok := object["$ref"].(string)
+		if !ok {
+			return object
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			s.warnings = append(s.warnings, fmt.Sprintf("external reference %s ignored", ref))
+			return nil
+		}
+		node = s.root
+		for _, token := range strings.Split(ref[2:], "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			parent, _ := node.(map[string]interface{})
+			node = parent[token]
+		}
+	}
+	return nil
+}
+
+// serverURL returns the URL of the first server, its variables set to
+// their defaults
+func (s *openAPISpec) serverURL() string {
+	servers, _ := s.root["servers"].([]interface{})
+	if len(servers) == 0 {
+		return ""
+	}
+	server := s.resolve(servers[0])
+	serverURL, _ := server["url"].(string)
+	variables, _ := server["variables"].(map[string]interface{})
+	return pathParamPattern.ReplaceAllStringFunc(serverURL, func(match string) string {
+		variable := s.resolve(variables[match[1:len(match)-1]])
+		if value, ok := variable["default"]; ok {
+			return formatParam(value)
+		}
+		return match
+	})
+}
+
+// operation converts an operation into a Config
+func (s *openAPISpec) operation(baseURL, path, method string, item, operation map[string]interface{}) Config {
+	name, _ := operation["operationId"].(string)
+	if name == "" {
+		name = method + " " + path
+	}
+	config := Config{Name: name, Method: method}
+	for _, tag := range toSlice(operation["tags"]) {
+		if tag, ok := tag.(string); ok {
+			config.Tags = append(config.Tags, tag)
+		}
+	}
+
+	header := make(http.Header)
+	query := url.Values{}
+	var rawQuery, cookies []string
+	pathValues := make(map[string]string)
+	for _, param := range s.parameters(item, operation) {
+		paramName, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		required, _ := param["required"].(bool)
+		value, documented := s.paramValue(param)
+		if in != "path" && !required && !documented {
+			continue
+		}
+		switch in {
+		case "path":
+			pathValues[paramName] = url.PathEscape(formatParam(value))
+		case "query":
+			query.Add(paramName, formatParam(value))
+		case "header":
+			header.Add(paramName, formatParam(value))
+		case "cookie":
+			cookies = append(cookies, paramName+"="+formatParam(value))
+		}
+	}
+	path = pathParamPattern.ReplaceAllStringFunc(path, func(match string) string {
+		if value, ok := pathValues[match[1:len(match)-1]]; ok {
+			return value
+		}
+		s.warn(name, "path parameter %s is not declared", match)
+		return match
+	})
+
+	if body := s.resolve(operation["requestBody"]); body != nil {
+		content, _ := body["content"].(map[string]interface{})
+		if contentType, payload, ok := s.requestBody(name, content); ok {
+			header.Set("Content-Type", contentType)
+			config.Body = payload
+		}
+	}
+
+	// credentials are ${VAR} references, which query escaping would break
+	for _, credential := range s.security(name, operation, &config) {
+		switch credential.in {
+		case "header":
+			header.Add(credential.name, credential.value)
+		case "query":
+			rawQuery = append(rawQuery, url.QueryEscape(credential.name)+"="+credential.value)
+		case "cookie":
+			cookies = append(cookies, credential.name+"="+credential.value)
+		}
+	}
+
+	config.URL = baseURL + path
+	if encoded := query.Encode(); encoded != "" {
+		rawQuery = append([]string{encoded}, rawQuery...)
+	}
+	if len(rawQuery) > 0 {
+		config.URL += "?" + strings.Join(rawQuery, "&")
+	}
+	if len(header) > 0 {
+		config.Header = header
+	}
+	config.Cookie = strings.Join(cookies, "; ")
+	return config
+}
+
+// parameters returns the parameters of an operation, those of its path
+// item included unless the operation overrides them
+func (s *openAPISpec) parameters(item, operation map[string]interface{}) (params []map[string]interface{}) {
+	seen := make(map[string]bool)
+	for _, node := range append(toSlice(operation["parameters"]), toSlice(item["parameters"])...) {
+		param := s.resolve(node)
+		if param == nil {
+			continue
+		}
+		key := fmt.Sprintf("%v %v", param["in"], param["name"])
+		if !seen[key] {
+			seen[key] = true
+			params = append(params, param)
+		}
+	}
+	return
+}
+
+// paramValue returns the value of a parameter, and whether it comes
+// from an example or a default rather than a bare schema
+func (s *openAPISpec) paramValue(param map[string]interface{}) (interface{}, bool) {
+	if value, ok := s.example(param); ok {
+		return value, true
+	}
+	schema := s.resolve(param["schema"])
+	if schema == nil {
+		// parameters may describe their value by a media type instead
+		content, _ := param["content"].(map[string]interface{})
+		for _, media := range content {
+			if media := s.resolve(media); media != nil {
+				if value, ok := s.example(media); ok {
+					return value, true
+				}
+				schema = s.resolve(media["schema"])
+			}
+			break
+		}
+	}
+	_, documented := schema["example"]
+	if _, ok := schema["default"]; ok {
+		documented = true
+	}
+	return s.sample(schema, nil), documented
+}
+
+// example returns the example of a parameter or media type: its
+// example, else the first of its examples
+func (s *openAPISpec) example(node map[string]interface{}) (interface{}, bool) {
+	if value, ok := node["example"]; ok {
+		return value, true
+	}
+	examples, _ := node["examples"].(map[string]interface{})
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value, ok := s.resolve(examples[name])["value"]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// requestBody synthesizes the body of the first media type call-it can
+// write, JSON first
+func (s *openAPISpec) requestBody(operation string, content map[string]interface{}) (contentType, body string, ok bool) {
+	types := make([]string, 0, len(content))
+	for contentType := range content {
+		types = append(types, contentType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return bodyPreference(types[i]) < bodyPreference(types[j]) || bodyPreference(types[i]) == bodyPreference(types[j]) && types[i] < types[j]
+	})
+	for _, contentType := range types {
+		media := s.resolve(content[contentType])
+		value, documented := s.example(media)
+		if !documented {
+			value = s.sample(media["schema"], nil)
+		}
+		switch preference := bodyPreference(contentType); {
+		case preference == 0:
+			raw, err := json.Marshal(value)
+			if err != nil {
+				s.warn(operation, "%s body: %v", contentType, err)
+				continue
+			}
+			return contentType, string(raw), true
+		case preference == 1:
+			values := url.Values{}
+			object, _ := value.(map[string]interface{})
+			for name, field := range object {
+				values.Set(name, formatParam(field))
+			}
+			return contentType, values.Encode(), true
+		case preference == 2:
+			return contentType, formatParam(value), true
+		}
+	}
+	if len(types) > 0 {
+		s.warn(operation, "no %s request body can be synthesized", strings.Join(types, " or "))
+	}
+	return "", "", false
+}
+
+// bodyPreference ranks the media types of request bodies: JSON, then
+// URL encoded forms, then text, other types not being synthesized
+func bodyPreference(contentType string) int {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	switch {
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		return 0
+	case mediaType == "application/x-www-form-urlencoded":
+		return 1
+	case strings.HasPrefix(mediaType, "text/"):
+		return 2
+	}
+	return 3
+}
+
+// sample synthesizes a value of a schema: its example, default, const
+// or first enum value, else a value of its type. refs holds the
+// references being sampled: a schema holding itself is left out of its
+// own value, which is nil
+func (s *openAPISpec) sample(node interface{}, refs map[string]bool) interface{} {
+	if object, ok := node.(map[string]interface{}); ok {
+		if ref, ok := object["$ref"].(string); ok {
+			if refs[ref] {
+				return nil
+			}
+			nested := map[string]bool{ref: true}
+			for ref := range refs {
+				nested[ref] = true
+			}
+			refs = nested
+		}
+	}
+	schema := s.resolve(node)
+	if schema == nil {
+		return nil
+	}
+	for _, key := range []string{"example", "default", "const"} {
+		if value, ok := schema[key]; ok {
+			return value
+		}
+	}
+	for _, key := range []string{"examples", "enum"} {
+		if values := toSlice(schema[key]); len(values) > 0 {
+			return values[0]
+		}
+	}
+	if parts := toSlice(schema["allOf"]); len(parts) > 0 {
+		merged := make(map[string]interface{})
+		for _, part := range parts {
+			object, _ := s.sample(part, refs).(map[string]interface{})
+			for name, value := range object {
+				merged[name] = value
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if alternatives := toSlice(schema[key]); len(alternatives) > 0 {
+			return s.sample(alternatives[0], refs)
+		}
+	}
+
+	schemaType, _ := schema["type"].(string)
+	for _, value := range toSlice(schema["type"]) {
+		// OpenAPI 3.1 types may be lists, such as [string, "null"]
+		if value != "null" {
+			schemaType, _ = value.(string)
+			break
+		}
+	}
+	switch {
+	case schemaType == "object", schemaType == "" && schema["properties"] != nil:
+		object := make(map[string]interface{})
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, property := range properties {
+			if readOnly, _ := s.resolve(property)["readOnly"].(bool); !readOnly {
+				if value := s.sample(property, refs); value != nil {
+					object[name] = value
+				}
+			}
+		}
+		return object
+	case schemaType == "array":
+		if item := s.sample(schema["items"], refs); item != nil {
+			return []interface{}{item}
+		}
+		return []interface{}{}
+	case schemaType == "integer", schemaType == "number":
+		if minimum, ok := schema["minimum"]; ok {
+			return minimum
+		}
+		return 1
+	case schemaType == "boolean":
+		return true
+	case schemaType == "string":
+		return sampleString(schema)
+	}
+	return nil
+}
+
+// sampleString returns a string of the format of a schema
+func sampleString(schema map[string]interface{}) string {
+	format, _ := schema["format"].(string)
+	value := map[string]string{
+		"date-time": "2024-01-01T00:00:00Z",
+		"date":      "2024-01-01",
+		"time":      "00:00:00",
+		"uuid":      "00000000-0000-4000-8000-000000000000",
+		"email":     "user@example.com",
+		"uri":       "https://example.com",
+		"url":       "https://example.com",
+		"hostname":  "example.com",
+		"ipv4":      "192.0.2.1",
+		"ipv6":      "2001:db8::1",
+		"byte":      "c3RyaW5n",
+		"password":  "password",
+	}[format]
+	if value == "" {
+		value = "string"
+	}
+	if minLength, ok := schema["minLength"].(float64); ok && int(minLength) > len(value) {
+		value += strings.Repeat("x", int(minLength)-len(value))
+	} else if minLength, ok := schema["minLength"].(int); ok && minLength > len(value) {
+		value += strings.Repeat("x", minLength-len(value))
+	}
+	return value
+}
+
+// openAPICredential is a credential sent as a header, query parameter
+// or cookie
+type openAPICredential struct {
+	in, name, value string
+}
+
+// security applies the first security requirement of an operation, or
+// else of the specification, to config: HTTP basic and bearer schemes
+// and OAuth2 client credentials set its Auth, API keys are returned
+func (s *openAPISpec) security(operation string, node map[string]interface{}, config *Config) (credentials []openAPICredential) {
+	requirements, ok := node["security"]
+	if !ok {
+		requirements = s.root["security"]
+	}
+	alternatives := toSlice(requirements)
+	if len(alternatives) == 0 {
+		return nil
+	}
+	requirement, _ := alternatives[0].(map[string]interface{})
+	names := make([]string, 0, len(requirement))
+	for name := range requirement {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	components, _ := s.root["components"].(map[string]interface{})
+	schemes, _ := components["securitySchemes"].(map[string]interface{})
+	setAuth := func(name string, auth *Auth) {
+		if config.Auth != nil {
+			s.warn(operation, "security scheme %s ignored, requests have a single auth", name)
+			return
+		}
+		config.Auth = auth
+	}
+	for _, name := range names {
+		scheme := s.resolve(schemes[name])
+		if scheme == nil {
+			s.warn(operation, "security scheme %s is not declared", name)
+			continue
+		}
+		variable := envName(strings.ToUpper(name))
+		schemeType, _ := scheme["type"].(string)
+		httpScheme, _ := scheme["scheme"].(string)
+		switch {
+		case schemeType == "http" && strings.EqualFold(httpScheme, "basic"):
+			setAuth(name, &Auth{Type: AuthBasic, Username: "${" + variable + "_USERNAME}", Password: "${" + variable + "_PASSWORD}"})
+		case schemeType == "http" && strings.EqualFold(httpScheme, "bearer"):
+			setAuth(name, &Auth{Type: AuthBearer, Token: "${" + variable + "_TOKEN}"})
+		case schemeType == "apiKey":
+			in, _ := scheme["in"].(string)
+			keyName, _ := scheme["name"].(string)
+			credentials = append(credentials, openAPICredential{in: in, name: keyName, value: "${" + variable + "}"})
+		case schemeType == "oauth2" || schemeType == "openIdConnect":
+			flows, _ := scheme["flows"].(map[string]interface{})
+			flow, _ := flows["clientCredentials"].(map[string]interface{})
+			tokenURL, _ := flow["tokenUrl"].(string)
+			if tokenURL == "" {
+				s.warn(operation, "%s: only the client credentials flow is run, a bearer token is read from ${%s_TOKEN}", name, variable)
+				setAuth(name, &Auth{Type: AuthBearer, Token: "${" + variable + "_TOKEN}"})
+				continue
+			}
+			auth := &Auth{Type: AuthOAuth2, TokenURL: tokenURL, ClientID: "${" + variable + "_CLIENT_ID}", ClientSecret: "${" + variable + "_CLIENT_SECRET}"}
+			for _, scope := range toSlice(requirement[name]) {
+				if scope, ok := scope.(string); ok {
+					auth.Scopes = append(auth.Scopes, scope)
+				}
+			}
+			setAuth(name, auth)
+		default:
+			s.warn(operation, "security scheme %s of type %s %s is not supported", name, schemeType, httpScheme)
+		}
+	}
+	return
+}
+
+// envName returns a name as a ${VAR} name, other characters than
+// letters, digits and _ replaced by _
+func envName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r == '_', r >= '0' && r <= '9' && i > 0:
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// formatParam writes a parameter value the way OpenAPI serializes it
+// by default: arrays comma separated, objects as JSON
+func formatParam(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case time.Time:
+		// YAML decodes unquoted dates and timestamps
+		if value.Equal(value.Truncate(24 * time.Hour)) {
+			return value.Format("2006-01-02")
+		}
+		return value.Format(time.RFC3339Nano)
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = formatParam(item)
+		}
+		return strings.Join(items, ",")
+	case map[string]interface{}:
+		raw, _ := json.Marshal(value)
+		return string(raw)
+	}
+	return fmt.Sprint(value)
+}
+
+// toSlice returns a node as a slice, nil when it is not one
+func toSlice(node interface{}) []interface{} {
+	slice, _ := node.([]interface{})
+	return slice
+}
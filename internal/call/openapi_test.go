@@ -0,0 +1,199 @@
+package call
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testOpenAPI = `openapi: 3.0.3
+info: {title: Petstore, version: "1.0"}
+servers:
+  - url: https://{env}.petstore.com/v1
+    variables:
+      env: {default: api}
+security:
+  - apiKey: []
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      tags: [pets]
+      parameters:
+        - {name: limit, in: query, required: true, schema: {type: integer, minimum: 5}}
+        - {name: sort, in: query, schema: {type: string, enum: [name, age]}}
+        - {name: species, in: query, example: [cat, dog], schema: {type: array, items: {type: string}}}
+        - {name: X-Request-Id, in: header, required: true, schema: {type: string, format: uuid}}
+      security: []
+    post:
+      operationId: createPet
+      tags: [pets, write]
+      security:
+        - petAuth: [pets:write]
+      requestBody:
+        content:
+          application/xml: {schema: {$ref: '#/components/schemas/Pet'}}
+          application/json: {schema: {$ref: '#/components/schemas/Pet'}}
+  /pets/{petId}:
+    parameters:
+      - {name: petId, in: path, required: true, schema: {type: integer}, example: 42}
+    get:
+      operationId: getPet
+      parameters:
+        - {name: session, in: cookie, required: true, schema: {type: string, minLength: 8}}
+    put:
+      security:
+        - basicAuth: []
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                name: {type: string, example: Rex}
+                born: {type: string, format: date}
+    delete:
+      operationId: deletePet
+      security:
+        - bearerAuth: []
+          basicAuth: []
+  /uploads:
+    post:
+      operationId: upload
+      security:
+        - webAuth: [read]
+      requestBody:
+        content:
+          multipart/form-data: {schema: {type: object}}
+components:
+  securitySchemes:
+    apiKey: {type: apiKey, in: query, name: api_key}
+    basicAuth: {type: http, scheme: basic}
+    bearerAuth: {type: http, scheme: bearer}
+    petAuth:
+      type: oauth2
+      flows:
+        clientCredentials: {tokenUrl: "https://auth.petstore.com/token", scopes: {"pets:write": write pets}}
+    webAuth:
+      type: oauth2
+      flows:
+        authorizationCode: {authorizationUrl: "https://auth.petstore.com/authorize", tokenUrl: "https://auth.petstore.com/token", scopes: {}}
+  schemas:
+    Pet:
+      allOf:
+        - $ref: '#/components/schemas/NewPet'
+        - type: object
+          required: [id]
+          properties:
+            id: {type: integer, readOnly: true}
+            tags: {type: array, items: {type: string}}
+            owner: {$ref: '#/components/schemas/Owner'}
+    NewPet:
+      type: object
+      properties:
+        name: {type: string, example: Rex}
+        kind: {type: string, enum: [cat, dog]}
+        born: {type: string, format: date-time}
+        vaccinated: {type: boolean}
+    Owner:
+      type: object
+      properties:
+        email: {type: string, format: email}
+        pets: {type: array, items: {$ref: '#/components/schemas/Pet'}}
+`
+
+func TestImportOpenAPI(t *testing.T) {
+	path := writeConfigFile(t, "spec.yaml", testOpenAPI)
+
+	configs, warnings, err := ImportOpenAPI(path, OpenAPIOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"deletePet: security scheme bearerAuth ignored, requests have a single auth",
+		"upload: no multipart/form-data request body can be synthesized",
+		"upload: webAuth: only the client credentials flow is run, a bearer token is read from ${WEBAUTH_TOKEN}",
+	}, warnings)
+	names := make([]string, len(configs))
+	for i, c := range configs {
+		names[i] = c.Name
+		assert.Nil(t, c.CheckDefaults(), c.Name)
+	}
+	assert.Equal(t, []string{"listPets", "createPet", "getPet", "PUT /pets/{petId}", "deletePet", "upload"}, names)
+
+	list := configs[0]
+	assert.Equal(t, "GET", list.Method)
+	assert.Equal(t, "https://api.petstore.com/v1/pets?limit=5&species=cat%2Cdog", list.URL)
+	assert.Equal(t, []string{"00000000-0000-4000-8000-000000000000"}, list.Header["X-Request-Id"])
+	assert.Equal(t, []string{"pets"}, list.Tags)
+	assert.Nil(t, list.Auth)
+
+	create := configs[1]
+	assert.Equal(t, "https://api.petstore.com/v1/pets", create.URL)
+	assert.Equal(t, []string{"application/json"}, create.Header["Content-Type"])
+	// the owner pets are pets, left out of themselves
+	assert.JSONEq(t, `{"name": "Rex", "kind": "cat", "born": "2024-01-01T00:00:00Z", "vaccinated": true, "tags": ["string"],
+		"owner": {"email": "user@example.com", "pets": []}}`, create.Body)
+	assert.Equal(t, &Auth{Type: AuthOAuth2, TokenURL: "https://auth.petstore.com/token", ClientID: "${PETAUTH_CLIENT_ID}",
+		ClientSecret: "${PETAUTH_CLIENT_SECRET}", Scopes: []string{"pets:write"}}, create.Auth)
+
+	get := configs[2]
+	assert.Equal(t, "https://api.petstore.com/v1/pets/42?api_key=${APIKEY}", get.URL)
+	assert.Equal(t, "session=stringxx", get.Cookie)
+
+	put := configs[3]
+	assert.Equal(t, "born=2024-01-01&name=Rex", put.Body)
+	assert.Equal(t, []string{"application/x-www-form-urlencoded"}, put.Header["Content-Type"])
+	assert.Equal(t, &Auth{Type: AuthBasic, Username: "${BASICAUTH_USERNAME}", Password: "${BASICAUTH_PASSWORD}"}, put.Auth)
+
+	assert.Equal(t, &Auth{Type: AuthBasic, Username: "${BASICAUTH_USERNAME}", Password: "${BASICAUTH_PASSWORD}"}, configs[4].Auth)
+	assert.Equal(t, &Auth{Type: AuthBearer, Token: "${WEBAUTH_TOKEN}"}, configs[5].Auth)
+	assert.Empty(t, configs[5].Body)
+}
+
+func TestImportOpenAPIBaseURL(t *testing.T) {
+	path := writeConfigFile(t, "spec.json", `{
+  "openapi": "3.1.0",
+  "servers": [{"url": "/api"}],
+  "paths": {"/status": {"get": {"responses": {"200": {"description": "ok"}}}}}
+}`)
+
+	_, _, err := ImportOpenAPI(path, OpenAPIOptions{})
+	assert.True(t, errors.Is(err, ErrNoBaseURL))
+
+	configs, warnings, err := ImportOpenAPI(path, OpenAPIOptions{BaseURL: "http://localhost:8080/api/"})
+	assert.Nil(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, []Config{{Name: "GET /status", Method: "GET", URL: "http://localhost:8080/api/status"}}, configs)
+}
+
+func TestImportOpenAPIErrors(t *testing.T) {
+	_, _, err := ImportOpenAPI(writeConfigFile(t, "swagger.json", `{"swagger": "2.0", "paths": {}}`), OpenAPIOptions{})
+	assert.True(t, errors.Is(err, ErrInvalidOpenAPI))
+	_, _, err = ImportOpenAPI(writeConfigFile(t, "empty.yaml", "openapi: 3.0.0\npaths: {}\n"), OpenAPIOptions{BaseURL: "http://localhost"})
+	assert.True(t, errors.Is(err, ErrInvalidOpenAPI))
+	_, _, err = ImportOpenAPI(writeConfigFile(t, "bad.json", `{"openapi": `), OpenAPIOptions{})
+	assert.True(t, errors.Is(err, ErrInvalidOpenAPI))
+}
+
+func TestSampleSchema(t *testing.T) {
+	spec := &openAPISpec{root: map[string]interface{}{}}
+	tests := []struct {
+		name   string
+		schema map[string]interface{}
+		want   interface{}
+	}{
+		{name: "example", schema: map[string]interface{}{"type": "string", "example": "x"}, want: "x"},
+		{name: "default", schema: map[string]interface{}{"type": "integer", "default": 3.0}, want: 3.0},
+		{name: "enum", schema: map[string]interface{}{"enum": []interface{}{"a", "b"}}, want: "a"},
+		{name: "nullable 3.1", schema: map[string]interface{}{"type": []interface{}{"null", "boolean"}}, want: true},
+		{name: "one of", schema: map[string]interface{}{"oneOf": []interface{}{map[string]interface{}{"type": "number"}}}, want: 1},
+		{name: "array without items", schema: map[string]interface{}{"type": "array"}, want: []interface{}{}},
+		{name: "ipv4", schema: map[string]interface{}{"type": "string", "format": "ipv4"}, want: "192.0.2.1"},
+		{name: "unknown", schema: map[string]interface{}{}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, spec.sample(tt.schema, nil))
+		})
+	}
+}
@@ -18,6 +18,111 @@ var (
 
 	// ErrEmptyName is an error with bad Config method
 	ErrEmptyName = errors.New("Request Config name cannot be nil")
+
+	// ErrEmptyStepURL is an error with a scenario step without URL
+	ErrEmptyStepURL = errors.New("Scenario step URL cannot be empty")
+
+	// ErrInvalidExtraction is an error with an unknown extraction source
+	ErrInvalidExtraction = errors.New("invalid extraction source")
+
+	// ErrInvalidCheck is an error with a malformed response check
+	ErrInvalidCheck = errors.New("invalid check")
+
+	// ErrStepsWithMix is an error with a Config holding both a scenario and a traffic mix
+	ErrStepsWithMix = errors.New("Request Config cannot have both steps and mix")
+
+	// ErrInvalidWeight is an error with a negative traffic mix weight
+	ErrInvalidWeight = errors.New("mix weight cannot be negative")
+
+	// ErrInvalidThinkTime is an error with an unknown or malformed think time distribution
+	ErrInvalidThinkTime = errors.New("invalid think time")
+
+	// ErrInvalidPacing is an error with a negative pacing
+	ErrInvalidPacing = errors.New("pacing cannot be negative")
+
+	// ErrUnknownFormat is an error with a config file in an unsupported format
+	ErrUnknownFormat = errors.New("unknown config format")
+
+	// ErrUndefinedVariable is an error with a config referencing unset environment variables
+	ErrUndefinedVariable = errors.New("undefined environment variable")
+
+	// ErrInvalidCookieJar is an error with an unknown cookie jar mode
+	ErrInvalidCookieJar = errors.New("invalid cookie jar mode")
+
+	// ErrInvalidAuth is an error with an incomplete or unknown auth block
+	ErrInvalidAuth = errors.New("invalid auth")
+
+	// ErrAuthFailed is an error when credentials could not be obtained
+	ErrAuthFailed = errors.New("authentication failed")
+
+	// ErrUnknownEnvironment is an error with an environment a config file does not define
+	ErrUnknownEnvironment = errors.New("unknown environment")
+
+	// ErrInvalidDocument is an error with a config file that is neither an array nor an object
+	ErrInvalidDocument = errors.New("invalid config document")
+
+	// ErrIncludeCycle is an error with config files including each other
+	ErrIncludeCycle = errors.New("config include cycle")
+
+	// ErrNoMatchingCase is an error with filters that leave no config entry to run
+	ErrNoMatchingCase = errors.New("no config case matches the filters")
+
+	// ErrWatchStdin is an error when watching a config read from the standard input
+	ErrWatchStdin = errors.New("cannot watch the standard input")
+
+	// ErrInvalidProxy is an error with a proxy that is not a URL
+	ErrInvalidProxy = errors.New("invalid proxy")
+
+	// ErrInvalidResolve is an error with a resolve entry that is not host:port:address
+	ErrInvalidResolve = errors.New("invalid resolve entry")
+
+	// ErrInvalidHTTPVersion is an error with an unsupported HTTP version
+	ErrInvalidHTTPVersion = errors.New("invalid HTTP version")
+
+	// ErrInvalidCurl is an error with a command that curl would not run
+	ErrInvalidCurl = errors.New("invalid curl command")
+
+	// ErrInvalidHAR is an error with a file that is not a HAR archive
+	ErrInvalidHAR = errors.New("invalid HAR file")
+
+	// ErrNoHAREntry is an error when no entry of a HAR file is left to import
+	ErrNoHAREntry = errors.New("no HAR entry matches the options")
+
+	// ErrInvalidOpenAPI is an error with a file that is not an OpenAPI 3 specification
+	ErrInvalidOpenAPI = errors.New("invalid OpenAPI specification")
+
+	// ErrNoBaseURL is an error when imported requests have no absolute URL to go to
+	ErrNoBaseURL = errors.New("no absolute base URL")
+
+	// ErrInvalidPostman is an error with a file that is not a Postman v2 collection or environment
+	ErrInvalidPostman = errors.New("invalid Postman file")
+
+	// ErrInvalidTarget is an error with a recorded target that is not an HTTP URL
+	ErrInvalidTarget = errors.New("invalid target URL")
+
+	// ErrNothingRecorded is an error when a recording holds no request
+	ErrNothingRecorded = errors.New("no request was recorded")
+
+	// ErrNoLogRequest is an error with an access log holding no request
+	ErrNoLogRequest = errors.New("no request found in the access log")
+
+	// ErrInvalidSpeed is an error with a negative replay speed
+	ErrInvalidSpeed = errors.New("invalid replay speed")
+
+	// ErrInvalidRoute is an error when a mock server route is invalid
+	ErrInvalidRoute = errors.New("invalid mock route")
+
+	// ErrInvalidResults is an error with a file that is not a JSON results report
+	ErrInvalidResults = errors.New("invalid results file")
+
+	// ErrUnknownRun is an error with an ID no run of the history has
+	ErrUnknownRun = errors.New("unknown run")
+
+	// ErrInvalidRun is an error with a run of the history that cannot be read
+	ErrInvalidRun = errors.New("invalid history run")
+
+	// ErrExtractionFailed is an error when a value could not be extracted from a response
+	ErrExtractionFailed = errors.New("could not extract value")
 )
 
 const (
@@ -91,15 +196,31 @@ func ParseIntArgument(args []string, position int, defaultValue int) (val int, e
 	return
 }
 
-// BuildCallsFromConfig parses a Config file and transforms the instructions into a list of ConcurrentCalls
+// BuildCallsFromConfig parses the default Config file and transforms the instructions into a list of ConcurrentCalls
 func BuildCallsFromConfig() (calls []ConcurrentCall, err error) {
-	callConfig, err := config()
+	return BuildCallsFromConfigFile(DefaultConfigPath)
+}
+
+// BuildCallsFromConfigFile parses a JSON, YAML or TOML Config file and transforms the instructions
+// into a list of ConcurrentCalls. StdinPath reads the Config from the standard input
+func BuildCallsFromConfigFile(path string) (calls []ConcurrentCall, err error) {
+	return BuildCallsWithOptions(path, LoadOptions{})
+}
+
+// BuildCallsWithOptions parses a Config file like BuildCallsFromConfigFile, applying options
+func BuildCallsWithOptions(path string, options LoadOptions) (calls []ConcurrentCall, err error) {
+	callConfig, err := LoadConfigWithOptions(path, options)
 	if err != nil {
 		return
 	}
-	for _, c := range callConfig {
+	return buildCalls(callConfig)
+}
+
+// buildCalls transforms Config entries into ConcurrentCalls
+func buildCalls(callConfig []Config) (calls []ConcurrentCall, err error) {
+	for i, c := range callConfig {
 		if err = c.CheckDefaults(); err != nil {
-			return
+			return nil, fmt.Errorf("entry %d (%s): %w", i, c.Name, err)
 		}
 		if c.Func != "" {
 			s, errF := getFuncResult(c.Func)
@@ -108,10 +229,11 @@ func BuildCallsFromConfig() (calls []ConcurrentCall, err error) {
 			}
 			c.Body = fmt.Sprintf(c.Body, s)
 		}
-		url, errP := url.ParseRequestURI(c.URL)
+		url, errP := parseConfigURL(c)
 		if errP != nil {
 			return nil, errP
 		}
+		registerAuthSecrets(c.Auth)
 		newCall := ConcurrentCall{
 			URL:                url,
 			Attempts:           c.Attempts,
@@ -123,6 +245,19 @@ func BuildCallsFromConfig() (calls []ConcurrentCall, err error) {
 	return
 }
 
+// parseConfigURL returns the endpoint of a Config. Scenarios and
+// traffic mixes are identified by their first URL, which may hold
+// variables
+func parseConfigURL(c Config) (*url.URL, error) {
+	if len(c.Steps) > 0 {
+		return url.Parse(c.Steps[0].URL)
+	}
+	if len(c.Mix) > 0 {
+		return url.Parse(c.Mix[0].URL)
+	}
+	return url.ParseRequestURI(c.URL)
+}
+
 func getFuncResult(fn string) (s string, err error) {
 	script := goscript.New(fn)
 	defer script.Close()
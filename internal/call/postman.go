@@ -0,0 +1,477 @@
+package call
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PostmanOptions tune how a Postman collection is imported
+type PostmanOptions struct {
+	Environments []string // Postman environment files, each imported as an environment
+}
+
+// postmanCollection is a Postman collection, format v2.0 or v2.1
+type postmanCollection struct {
+	Info *struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item     []postmanItem  `json:"item"`
+	Auth     *postmanAuth   `json:"auth"`
+	Variable []postmanPair  `json:"variable"`
+	Event    []postmanEvent `json:"event"`
+}
+
+// postmanItem is a request, or a folder when it holds items
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []postmanItem   `json:"item"`
+	Request *postmanRequest `json:"request"`
+	Auth    *postmanAuth    `json:"auth"`
+	Event   []postmanEvent  `json:"event"`
+}
+
+type postmanRequest struct {
+	Method string        `json:"method"`
+	Header []postmanPair `json:"header"`
+	URL    postmanURL    `json:"url"`
+	Body   *postmanBody  `json:"body"`
+	Auth   *postmanAuth  `json:"auth"`
+}
+
+// UnmarshalJSON accepts both a request object and a bare URL
+func (r *postmanRequest) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*r = postmanRequest{Method: http.MethodGet, URL: postmanURL{Raw: raw}}
+		return nil
+	}
+	type request postmanRequest
+	return json.Unmarshal(data, (*request)(r))
+}
+
+type postmanURL struct {
+	Raw      string        `json:"raw"`
+	Variable []postmanPair `json:"variable"` // values of the :name path variables
+}
+
+// UnmarshalJSON accepts both a URL object and a bare URL
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &u.Raw); err == nil {
+		return nil
+	}
+	type postmanURLObject postmanURL
+	return json.Unmarshal(data, (*postmanURLObject)(u))
+}
+
+type postmanBody struct {
+	Mode       string        `json:"mode"`
+	Raw        string        `json:"raw"`
+	URLEncoded []postmanPair `json:"urlencoded"`
+	FormData   []postmanPair `json:"formdata"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+// postmanPair is a header, parameter, form field or variable. Values
+// of auth parameters may be numbers or booleans
+type postmanPair struct {
+	Key      string      `json:"key"`
+	Value    interface{} `json:"value"`
+	Disabled bool        `json:"disabled"`
+	Enabled  *bool       `json:"enabled"` // environment values
+	Type     string      `json:"type"`    // file form fields
+	Src      interface{} `json:"src"`     // paths of file form fields
+}
+
+// on tells whether a pair is used
+func (p postmanPair) on() bool {
+	return !p.Disabled && (p.Enabled == nil || *p.Enabled)
+}
+
+// postmanAuth is an auth setting, its parameters listed under its type
+type postmanAuth struct {
+	Type   string
+	Params map[string]string
+}
+
+// UnmarshalJSON reads the parameters of the auth type, a list of pairs
+// in v2.1 and an object in v2.0
+func (a *postmanAuth) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw["type"], &a.Type); err != nil {
+		return err
+	}
+	a.Params = make(map[string]string)
+	var pairs []postmanPair
+	if err := json.Unmarshal(raw[a.Type], &pairs); err == nil {
+		for _, pair := range pairs {
+			a.Params[pair.Key] = formatParam(pair.Value)
+		}
+		return nil
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(raw[a.Type], &object); err == nil {
+		for key, value := range object {
+			a.Params[key] = formatParam(value)
+		}
+	}
+	return nil
+}
+
+type postmanEvent struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec interface{} `json:"exec"` // lines of code, or the code
+	} `json:"script"`
+}
+
+// postmanEnvironment is a Postman environment file
+type postmanEnvironment struct {
+	Name   string        `json:"name"`
+	Values []postmanPair `json:"values"`
+}
+
+var (
+	postmanVariablePattern = regexp.MustCompile(`{{([^{}]+)}}`)
+	postmanPathPattern     = regexp.MustCompile(`/:([A-Za-z_][\w-]*)`)
+)
+
+// postmanImport holds the state of a collection import
+type postmanImport struct {
+	variables map[string]string // collection variables, the defaults of the references
+	warned    map[string]bool
+	warnings  []string
+}
+
+// ImportPostman reads a Postman collection, v2.0 or v2.1, into Config
+// entries, one per request, tagged with the names of the folders holding
+// the request. Requests inherit the auth of their folders and of the
+// collection. {{variables}} become ${VAR} references, whose default is
+// the value of the collection variable, and the variables of each
+// environment file become an environment. What cannot be imported, such
+// as scripts, is reported by warnings
+func ImportPostman(path string, options PostmanOptions) (configs []Config, environments map[string]Environment, warnings []string, err error) {
+	raw, err := readConfigFile(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var collection postmanCollection
+	if err := json.Unmarshal(raw, &collection); err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %v", ErrInvalidPostman, err)
+	}
+	if collection.Info == nil || strings.Contains(collection.Info.Schema, "v1.") {
+		return nil, nil, nil, fmt.Errorf("%w: only v2 collections are supported", ErrInvalidPostman)
+	}
+
+	p := &postmanImport{variables: make(map[string]string), warned: make(map[string]bool)}
+	for _, variable := range collection.Variable {
+		if variable.on() {
+			p.variables[variable.Key] = formatParam(variable.Value)
+		}
+	}
+	p.checkScripts("collection", collection.Event)
+	configs = p.items(collection.Item, nil, collection.Auth)
+	if len(configs) == 0 {
+		return nil, nil, p.warnings, fmt.Errorf("%w: no request", ErrInvalidPostman)
+	}
+
+	for _, environmentPath := range options.Environments {
+		raw, err := readConfigFile(environmentPath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		var environment postmanEnvironment
+		if err := json.Unmarshal(raw, &environment); err != nil || environment.Values == nil {
+			return nil, nil, nil, fmt.Errorf("%w: %s is not an environment", ErrInvalidPostman, environmentPath)
+		}
+		name := environment.Name
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(environmentPath), filepath.Ext(environmentPath))
+		}
+		variables := make(map[string]string)
+		for _, value := range environment.Values {
+			if value.on() {
+				variables[envName(value.Key)] = formatParam(value.Value)
+			}
+		}
+		if environments == nil {
+			environments = make(map[string]Environment)
+		}
+		environments[name] = Environment{Variables: variables}
+	}
+	return configs, environments, p.warnings, nil
+}
+
+// warn records a problem, once
+func (p *postmanImport) warn(format string, args ...interface{}) {
+	warning := fmt.Sprintf(format, args...)
+	if !p.warned[warning] {
+		p.warned[warning] = true
+		p.warnings = append(p.warnings, warning)
+	}
+}
+
+// checkScripts warns about the scripts of an item, which are not run
+func (p *postmanImport) checkScripts(name string, events []postmanEvent) {
+	for _, event := range events {
+		lines := toSlice(event.Script.Exec)
+		if code, ok := event.Script.Exec.(string); ok {
+			lines = []interface{}{code}
+		}
+		for _, line := range lines {
+			if line, _ := line.(string); strings.TrimSpace(line) != "" {
+				p.warn("%s: %s script is not run", name, event.Listen)
+				break
+			}
+		}
+	}
+}
+
+// items converts the requests of a folder, tagged with the folders
+// holding them and authenticating with auth unless they have their own
+func (p *postmanImport) items(items []postmanItem, tags []string, auth *postmanAuth) (configs []Config) {
+	for _, item := range items {
+		p.checkScripts(item.Name, item.Event)
+		if item.Request == nil {
+			folderAuth := auth
+			if item.Auth != nil {
+				folderAuth = item.Auth
+			}
+			configs = append(configs, p.items(item.Item, append(tags[:len(tags):len(tags)], item.Name), folderAuth)...)
+			continue
+		}
+		requestAuth := auth
+		if item.Request.Auth != nil {
+			requestAuth = item.Request.Auth
+		}
+		configs = append(configs, p.request(item.Name, *item.Request, tags, requestAuth))
+	}
+	return
+}
+
+// request converts a request into a Config
+func (p *postmanImport) request(name string, request postmanRequest, tags []string, auth *postmanAuth) Config {
+	method := strings.ToUpper(request.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	config := Config{Name: name, Method: method, Tags: append([]string(nil), tags...)}
+
+	pathValues := make(map[string]string)
+	for _, variable := range request.URL.Variable {
+		pathValues[variable.Key] = formatParam(variable.Value)
+	}
+	rawURL := postmanPathPattern.ReplaceAllStringFunc(request.URL.Raw, func(match string) string {
+		if value := pathValues[match[2:]]; value != "" {
+			return "/" + value
+		}
+		return match
+	})
+	config.URL = p.template(rawURL)
+
+	header := make(http.Header)
+	for _, pair := range request.Header {
+		if !pair.on() || pair.Key == "" {
+			continue
+		}
+		value := p.template(formatParam(pair.Value))
+		if strings.EqualFold(pair.Key, "Cookie") {
+			config.Cookie = joinCookies(config.Cookie, value)
+		} else {
+			header.Add(pair.Key, value)
+		}
+	}
+	if request.Body != nil {
+		body, contentType := p.body(name, *request.Body)
+		config.Body = body
+		if contentType != "" && header.Get("Content-Type") == "" {
+			header.Set("Content-Type", contentType)
+		}
+	}
+	if auth != nil {
+		p.auth(name, *auth, &config, header)
+	}
+	if len(header) > 0 {
+		config.Header = header
+	}
+	return config
+}
+
+// body returns the body of a request and its content type
+func (p *postmanImport) body(name string, body postmanBody) (string, string) {
+	switch body.Mode {
+	case "", "raw":
+		if body.Raw == "" {
+			return "", ""
+		}
+		contentType := map[string]string{
+			"json":       "application/json",
+			"xml":        "application/xml",
+			"html":       "text/html",
+			"javascript": "application/javascript",
+			"text":       "text/plain",
+		}[body.Options.Raw.Language]
+		return p.template(body.Raw), contentType
+	case "urlencoded":
+		var fields []string
+		for _, pair := range body.URLEncoded {
+			if pair.on() {
+				fields = append(fields, p.escapeTemplate(pair.Key)+"="+p.escapeTemplate(formatParam(pair.Value)))
+			}
+		}
+		return strings.Join(fields, "&"), "application/x-www-form-urlencoded"
+	case "formdata":
+		return p.formData(name, body.FormData)
+	case "graphql":
+		if body.GraphQL == nil {
+			return "", ""
+		}
+		payload := map[string]interface{}{"query": body.GraphQL.Query}
+		var variables interface{}
+		if err := json.Unmarshal([]byte(body.GraphQL.Variables), &variables); err == nil {
+			payload["variables"] = variables
+		}
+		raw, _ := json.Marshal(payload)
+		return p.template(string(raw)), "application/json"
+	}
+	p.warn("%s: %s body is not imported", name, body.Mode)
+	return "", ""
+}
+
+// formData encodes form fields as a multipart body. Files are read from
+// their paths
+func (p *postmanImport) formData(name string, fields []postmanPair) (string, string) {
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+	if err := writer.SetBoundary(curlFormBoundary); err != nil {
+		return "", ""
+	}
+	for _, field := range fields {
+		if !field.on() {
+			continue
+		}
+		header := make(textproto.MIMEHeader)
+		disposition := fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(field.Key))
+		content := []byte(p.template(formatParam(field.Value)))
+		if field.Type == "file" {
+			path := formatParam(field.Src)
+			raw, err := ioutil.ReadFile(path)
+			if err != nil {
+				p.warn("%s: form file %s is not imported: %v", name, field.Key, err)
+				continue
+			}
+			content = raw
+			disposition += fmt.Sprintf(`; filename="%s"`, escapeQuotes(filepath.Base(path)))
+			contentType := mime.TypeByExtension(filepath.Ext(path))
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			header.Set("Content-Type", contentType)
+		}
+		header.Set("Content-Disposition", disposition)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return "", ""
+		}
+		part.Write(content)
+	}
+	writer.Close()
+	return buffer.String(), "multipart/form-data; boundary=" + curlFormBoundary
+}
+
+// auth applies an auth setting to the Config of a request
+func (p *postmanImport) auth(name string, auth postmanAuth, config *Config, header http.Header) {
+	param := func(key string) string {
+		return p.template(auth.Params[key])
+	}
+	switch auth.Type {
+	case "noauth":
+	case "basic":
+		config.Auth = &Auth{Type: AuthBasic, Username: param("username"), Password: param("password")}
+	case "bearer":
+		config.Auth = &Auth{Type: AuthBearer, Token: param("token")}
+	case "apikey":
+		key, value := param("key"), param("value")
+		if auth.Params["in"] == "query" {
+			separator := "?"
+			if strings.Contains(config.URL, "?") {
+				separator = "&"
+			}
+			config.URL += separator + url.QueryEscape(key) + "=" + value
+		} else {
+			header.Set(key, value)
+		}
+	case "awsv4":
+		config.Auth = &Auth{
+			Type: AuthSigV4, KeyID: param("accessKey"), Secret: param("secretKey"),
+			Region: param("region"), Service: param("service"), SessionToken: param("sessionToken"),
+		}
+	case "oauth2":
+		if auth.Params["grant_type"] == "client_credentials" && auth.Params["accessTokenUrl"] != "" {
+			config.Auth = &Auth{
+				Type: AuthOAuth2, TokenURL: param("accessTokenUrl"),
+				ClientID: param("clientId"), ClientSecret: param("clientSecret"),
+				Scopes: strings.Fields(param("scope")),
+			}
+		} else if token := param("accessToken"); token != "" {
+			config.Auth = &Auth{Type: AuthBearer, Token: token}
+			p.warn("%s: only the client credentials grant is run, the saved access token is used", name)
+		} else {
+			p.warn("%s: only the client credentials grant of oauth2 is supported, requests are not authenticated", name)
+		}
+	default:
+		p.warn("%s: %s auth is not supported, requests are not authenticated", name, auth.Type)
+	}
+}
+
+// template turns the {{variables}} of s into ${VAR} references, which
+// default to the value of the collection variable. Dynamic variables,
+// such as {{$guid}}, are kept
+func (p *postmanImport) template(s string) string {
+	return postmanVariablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := strings.TrimSpace(match[2 : len(match)-2])
+		if strings.HasPrefix(name, "$") {
+			p.warn("dynamic variable %s is not supported", match)
+			return match
+		}
+		if value, ok := p.variables[name]; ok && !strings.Contains(value, "}") {
+			return "${" + envName(name) + ":-" + value + "}"
+		}
+		return "${" + envName(name) + "}"
+	})
+}
+
+// escapeTemplate URL encodes s, leaving the references to variables as
+// they are
+func (p *postmanImport) escapeTemplate(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, match := range postmanVariablePattern.FindAllStringIndex(s, -1) {
+		b.WriteString(url.QueryEscape(s[last:match[0]]))
+		b.WriteString(p.template(s[match[0]:match[1]]))
+		last = match[1]
+	}
+	b.WriteString(url.QueryEscape(s[last:]))
+	return b.String()
+}
@@ -0,0 +1,210 @@
+package call
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPostmanCollection = `{
+  "info": {"name": "Shop", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]},
+  "variable": [
+    {"key": "baseUrl", "value": "https://api.shop.com"},
+    {"key": "token", "value": ""}
+  ],
+  "item": [
+    {"name": "health", "request": "{{baseUrl}}/health"},
+    {
+      "name": "Catalog",
+      "item": [
+        {
+          "name": "Products",
+          "item": [
+            {
+              "name": "get product",
+              "event": [{"listen": "test", "script": {"exec": ["pm.test('ok', () => pm.response.to.have.status(200))"]}}],
+              "request": {
+                "method": "GET",
+                "header": [
+                  {"key": "Accept", "value": "application/json"},
+                  {"key": "X-Debug", "value": "1", "disabled": true},
+                  {"key": "Cookie", "value": "sid={{sid}}"}
+                ],
+                "url": {"raw": "{{baseUrl}}/products/:id?lang=en", "variable": [{"key": "id", "value": "{{productId}}"}]}
+              }
+            }
+          ]
+        },
+        {
+          "name": "create product",
+          "request": {
+            "method": "post",
+            "header": [{"key": "X-Request-Id", "value": "{{$guid}}"}],
+            "body": {"mode": "raw", "raw": "{\"name\": \"{{productName}}\"}", "options": {"raw": {"language": "json"}}},
+            "url": "{{baseUrl}}/products"
+          }
+        }
+      ]
+    },
+    {
+      "name": "Admin",
+      "auth": {"type": "basic", "basic": [{"key": "username", "value": "admin"}, {"key": "password", "value": "{{adminPassword}}"}]},
+      "item": [
+        {
+          "name": "login",
+          "request": {
+            "method": "POST",
+            "body": {"mode": "urlencoded", "urlencoded": [
+              {"key": "user", "value": "ana&bob"},
+              {"key": "next", "value": "{{baseUrl}}/home"},
+              {"key": "debug", "value": "1", "disabled": true}
+            ]},
+            "url": {"raw": "{{baseUrl}}/login"}
+          }
+        },
+        {"name": "public", "request": {"method": "GET", "auth": {"type": "noauth"}, "url": "{{baseUrl}}/public"}},
+        {"name": "digest", "request": {"method": "GET", "auth": {"type": "digest", "digest": []}, "url": "{{baseUrl}}/digest"}}
+      ]
+    },
+    {
+      "name": "search",
+      "request": {
+        "method": "POST",
+        "auth": {"type": "apikey", "apikey": [{"key": "key", "value": "api_key"}, {"key": "value", "value": "{{apiKey}}"}, {"key": "in", "value": "query"}]},
+        "body": {"mode": "graphql", "graphql": {"query": "query { products { id } }", "variables": "{\"first\": 10}"}},
+        "url": "{{baseUrl}}/graphql?v=2"
+      }
+    },
+    {
+      "name": "queues",
+      "request": {
+        "method": "GET",
+        "auth": {"type": "awsv4", "awsv4": {"accessKey": "{{awsKey}}", "secretKey": "{{awsSecret}}", "region": "us-east-1", "service": "sqs"}},
+        "url": "https://sqs.us-east-1.amazonaws.com/"
+      }
+    },
+    {
+      "name": "token",
+      "request": {
+        "method": "GET",
+        "auth": {"type": "oauth2", "oauth2": [
+          {"key": "grant_type", "value": "client_credentials"},
+          {"key": "accessTokenUrl", "value": "https://auth.shop.com/token"},
+          {"key": "clientId", "value": "{{clientId}}"},
+          {"key": "clientSecret", "value": "{{clientSecret}}"},
+          {"key": "scope", "value": "read write"}
+        ]},
+        "url": "{{baseUrl}}/me"
+      }
+    }
+  ]
+}`
+
+const testPostmanEnvironment = `{
+  "name": "staging",
+  "values": [
+    {"key": "baseUrl", "value": "https://staging.shop.com", "enabled": true},
+    {"key": "token", "value": "st4ging", "enabled": true},
+    {"key": "old", "value": "x", "enabled": false}
+  ]
+}`
+
+func TestImportPostman(t *testing.T) {
+	path := writeConfigFile(t, "shop.postman_collection.json", testPostmanCollection)
+
+	configs, environments, warnings, err := ImportPostman(path, PostmanOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, environments)
+	assert.Equal(t, []string{
+		"get product: test script is not run",
+		"dynamic variable {{$guid}} is not supported",
+		"digest: digest auth is not supported, requests are not authenticated",
+	}, warnings)
+	names := make([]string, len(configs))
+	for i, c := range configs {
+		names[i] = c.Name
+	}
+	assert.Equal(t, []string{"health", "get product", "create product", "login", "public", "digest", "search", "queues", "token"}, names)
+
+	bearer := &Auth{Type: AuthBearer, Token: "${token:-}"}
+	assert.Equal(t, Config{Name: "health", Method: "GET", URL: "${baseUrl:-https://api.shop.com}/health", Auth: bearer}, configs[0])
+	assert.Equal(t, Config{
+		Name: "get product", Method: "GET", Tags: []string{"Catalog", "Products"},
+		URL:    "${baseUrl:-https://api.shop.com}/products/${productId}?lang=en",
+		Header: map[string][]string{"Accept": {"application/json"}},
+		Cookie: "sid=${sid}",
+		Auth:   bearer,
+	}, configs[1])
+
+	create := configs[2]
+	assert.Equal(t, "POST", create.Method)
+	assert.Equal(t, []string{"Catalog"}, create.Tags)
+	assert.Equal(t, `{"name": "${productName}"}`, create.Body)
+	assert.Equal(t, []string{"application/json"}, create.Header["Content-Type"])
+	assert.Equal(t, []string{"{{$guid}}"}, create.Header["X-Request-Id"])
+
+	login := configs[3]
+	assert.Equal(t, []string{"Admin"}, login.Tags)
+	assert.Equal(t, "user=ana%26bob&next=${baseUrl:-https://api.shop.com}%2Fhome", login.Body)
+	assert.Equal(t, []string{"application/x-www-form-urlencoded"}, login.Header["Content-Type"])
+	assert.Equal(t, &Auth{Type: AuthBasic, Username: "admin", Password: "${adminPassword}"}, login.Auth)
+	assert.Nil(t, configs[4].Auth)
+	assert.Nil(t, configs[5].Auth)
+
+	search := configs[6]
+	assert.Equal(t, "${baseUrl:-https://api.shop.com}/graphql?v=2&api_key=${apiKey}", search.URL)
+	assert.JSONEq(t, `{"query": "query { products { id } }", "variables": {"first": 10}}`, search.Body)
+	assert.Nil(t, search.Auth)
+
+	assert.Equal(t, &Auth{Type: AuthSigV4, KeyID: "${awsKey}", Secret: "${awsSecret}", Region: "us-east-1", Service: "sqs"}, configs[7].Auth)
+	assert.Equal(t, &Auth{Type: AuthOAuth2, TokenURL: "https://auth.shop.com/token", ClientID: "${clientId}",
+		ClientSecret: "${clientSecret}", Scopes: []string{"read", "write"}}, configs[8].Auth)
+}
+
+func TestImportPostmanEnvironments(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"shop.json":    testPostmanCollection,
+		"staging.json": testPostmanEnvironment,
+		"local.json":   `{"values": [{"key": "baseUrl", "value": "http://localhost:8080"}]}`,
+	})
+
+	configs, environments, _, err := ImportPostman(filepath.Join(dir, "shop.json"), PostmanOptions{
+		Environments: []string{filepath.Join(dir, "staging.json"), filepath.Join(dir, "local.json")},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]Environment{
+		"staging": {Variables: map[string]string{"baseUrl": "https://staging.shop.com", "token": "st4ging"}},
+		"local":   {Variables: map[string]string{"baseUrl": "http://localhost:8080"}},
+	}, environments)
+
+	// the imported document loads, the environment overriding the collection variables
+	raw, err := json.Marshal(document{Cases: configs[:1], Environments: environments})
+	assert.Nil(t, err)
+	path := filepath.Join(dir, "config.json")
+	assert.Nil(t, ioutil.WriteFile(path, raw, 0600))
+	loaded, err := LoadConfigWithOptions(path, LoadOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://api.shop.com/health", loaded[0].URL)
+	loaded, err = LoadConfigWithOptions(path, LoadOptions{Env: "staging"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://staging.shop.com/health", loaded[0].URL)
+	assert.Equal(t, "st4ging", loaded[0].Auth.Token)
+}
+
+func TestImportPostmanErrors(t *testing.T) {
+	_, _, _, err := ImportPostman(writeConfigFile(t, "v1.json", `{"info": {"schema": "https://schema.getpostman.com/json/collection/v1.0.0/collection.json"}, "requests": []}`), PostmanOptions{})
+	assert.True(t, errors.Is(err, ErrInvalidPostman))
+	_, _, _, err = ImportPostman(writeConfigFile(t, "bad.json", `[]`), PostmanOptions{})
+	assert.True(t, errors.Is(err, ErrInvalidPostman))
+	_, _, _, err = ImportPostman(writeConfigFile(t, "empty.json", `{"info": {"name": "empty"}, "item": []}`), PostmanOptions{})
+	assert.True(t, errors.Is(err, ErrInvalidPostman))
+
+	path := writeConfigFile(t, "shop.json", testPostmanCollection)
+	_, _, _, err = ImportPostman(path, PostmanOptions{Environments: []string{path}})
+	assert.True(t, errors.Is(err, ErrInvalidPostman))
+}
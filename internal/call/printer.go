@@ -1,9 +1,13 @@
 package call
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 )
@@ -27,7 +31,7 @@ func PrintResults(result Result) {
 
 		if firstLine {
 			table.Append([]string{
-				result.URL.String(),
+				MaskSecrets(result.URL.String()),
 				strconv.Itoa(statusCode),
 				strconv.Itoa(benchmark.total),
 				statusAvgExecution,
@@ -47,11 +51,407 @@ func PrintResults(result Result) {
 		}
 	}
 
-	table.SetFooter([]string{"ELAPSED " + totalExecution, "", "", "", "", "", " "})
+	footer := "ELAPSED " + totalExecution
+	if result.environment != "" {
+		footer += " ENV " + result.environment
+	}
+	table.SetFooter([]string{footer, "", "", "", "", "", " "})
+	table.Render()
+
+	if len(result.checks) > 0 {
+		printChecks(result.checks)
+	}
+	if len(result.steps) > 0 {
+		printSteps(result)
+	}
+	if len(result.endpoints) > 0 {
+		printEndpoints(result)
+	}
+}
+
+// printEndpoints outputs one row per endpoint and status code of a
+// traffic mix, along with the share of requests each endpoint got
+func printEndpoints(result Result) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ENDPOINT", "URL", "SHARE", "STATUS", "TIMES", "AVG", "MIN", "MAX"})
+	table.SetAutoFormatHeaders(false)
+
+	total := 0
+	for _, benchmark := range result.status {
+		total += benchmark.total
+	}
+	for i, endpoint := range result.endpoints {
+		name := endpoint.name
+		if name == "" {
+			name = strconv.Itoa(i + 1)
+		}
+		endpointTotal := 0
+		for _, benchmark := range endpoint.status {
+			endpointTotal += benchmark.total
+		}
+		firstLine := true
+		for statusCode, benchmark := range endpoint.status {
+			row := []string{"", "", "", strconv.Itoa(statusCode), strconv.Itoa(benchmark.total),
+				formatTime(benchmark.execution / float64(benchmark.total)), " ", " "}
+			if firstLine {
+				row[0], row[1] = MaskSecrets(name), MaskSecrets(endpoint.URL.String())
+				row[2] = formatRatio(float64(endpointTotal) / float64(total))
+				row[6], row[7] = formatTime(endpoint.minExecution), formatTime(endpoint.maxExecution)
+				firstLine = false
+			}
+			table.Append(row)
+		}
+	}
+	table.Render()
+
+	for _, endpoint := range result.endpoints {
+		if len(endpoint.checks) > 0 {
+			printChecks(endpoint.checks)
+		}
+	}
+}
+
+// printChecks outputs the pass/fail ratio of every response check
+func printChecks(checks []CheckBenchmark) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"CHECK", "PASSED", "FAILED", "RATIO"})
+	table.SetAutoFormatHeaders(false)
+
+	for _, check := range checks {
+		table.Append([]string{
+			MaskSecrets(check.name),
+			strconv.Itoa(check.passed),
+			strconv.Itoa(check.failed),
+			formatRatio(check.GetRatio())})
+	}
+	table.Render()
+}
+
+// printSteps outputs one row per step and status code of a scenario,
+// followed by how many flows were interrupted
+func printSteps(result Result) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"STEP", "URL", "STATUS", "TIMES", "AVG", "MIN", "MAX"})
+	table.SetAutoFormatHeaders(false)
+
+	for i, step := range result.steps {
+		name := step.name
+		if name == "" {
+			name = strconv.Itoa(i + 1)
+		}
+		firstLine := true
+		for statusCode, benchmark := range step.status {
+			row := []string{"", "", strconv.Itoa(statusCode), strconv.Itoa(benchmark.total),
+				formatTime(benchmark.execution / float64(benchmark.total)), " ", " "}
+			if firstLine {
+				row[0], row[1] = MaskSecrets(name), MaskSecrets(step.URL.String())
+				row[5], row[6] = formatTime(step.minExecution), formatTime(step.maxExecution)
+				firstLine = false
+			}
+			table.Append(row)
+		}
+	}
+
+	table.SetFooter([]string{"FAILED FLOWS " + strconv.Itoa(result.failedFlows), "", "", "", "", "", " "})
 	table.Render()
+
+	for _, step := range result.steps {
+		if len(step.checks) > 0 {
+			printChecks(step.checks)
+		}
+	}
+}
+
+// PrintSuiteResults outputs the results of a suite, one case after
+// another
+func PrintSuiteResults(results []Result) {
+	for _, result := range results {
+		printCase(result)
+		PrintResults(result)
+	}
+}
+
+// printCase outputs the name of the case of a result and the
+// environment it ran in
+func printCase(result Result) {
+	if result.name != "" {
+		fmt.Println("Case: ", MaskSecrets(result.name))
+	}
+	if result.environment != "" {
+		fmt.Println("Environment: ", result.environment)
+	}
+}
+
+// PrintWatchRun outputs a run of a Watcher: the files that changed and
+// the results of the cases that ran, each followed by its deltas with
+// the previous run of the case
+func PrintWatchRun(run WatchRun) {
+	if len(run.changed) > 0 {
+		fmt.Println("Changed: ", strings.Join(run.changed, ", "))
+	}
+	if run.err != nil {
+		fmt.Println("Error: ", MaskSecrets(run.err.Error()))
+		return
+	}
+	if len(run.results) == 0 {
+		fmt.Println("No case changed")
+		return
+	}
+	for i, result := range run.results {
+		printCase(result)
+		PrintResults(result)
+		if run.previous[i] != nil {
+			printDeltas(CompareResults(*run.previous[i], result))
+		}
+	}
+	if run.historyErr != nil {
+		fmt.Println("Run not saved to the history: ", MaskSecrets(run.historyErr.Error()))
+	}
+}
+
+// printDeltas outputs the previous and current values of metrics
+func printDeltas(deltas []Delta) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"METRIC", "PREVIOUS", "CURRENT", "CHANGE"})
+	table.SetAutoFormatHeaders(false)
+
+	for _, delta := range deltas {
+		table.Append([]string{
+			delta.metric,
+			delta.Format(delta.previous),
+			delta.Format(delta.current),
+			delta.FormatChange()})
+	}
+	table.Render()
+}
+
+// PrintComparison outputs the deltas of every case compared, its name
+// on its first row, flagging regressions. Cases missing from a run are
+// listed with a single row saying so
+func PrintComparison(comparison Comparison) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"CASE", "STATUS", "METRIC", "BASELINE", "CURRENT", "CHANGE", "REGRESSION"})
+	table.SetAutoFormatHeaders(false)
+
+	for _, c := range comparison.cases {
+		name := MaskSecrets(c.name)
+		if c.missing != "" {
+			table.Append([]string{name, "", "", "", "", "missing in " + c.missing, ""})
+			continue
+		}
+		for i, delta := range c.deltas {
+			row := []string{"", "ALL", delta.metric, delta.Format(delta.previous), delta.Format(delta.current),
+				delta.FormatChangeRatio(), ""}
+			if i == 0 {
+				row[0] = name
+			}
+			if delta.status != "" {
+				row[1] = delta.status
+			}
+			if delta.regression {
+				row[6] = "YES"
+			}
+			table.Append(row)
+		}
+	}
+	table.SetFooter([]string{"REGRESSIONS " + strconv.Itoa(comparison.Regressions()),
+		"TOLERANCE " + strconv.FormatFloat(comparison.tolerance, 'f', -1, 64) + "%", "", "", "", "", " "})
+	table.Render()
+}
+
+// PrintHistoryList outputs the runs of a history, one per row, with
+// their main metrics
+func PrintHistoryList(runs []Run) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "TIME", "CASE", "COMMIT", "VERSION", "REQUESTS", "RPS", "P95", "ERRORS"})
+	table.SetAutoFormatHeaders(false)
+
+	for _, run := range runs {
+		table.Append(historyRow(run))
+	}
+	table.Render()
+}
+
+// PrintHistoryRun outputs a run of a history: where it comes from, its
+// results per status code and the config it ran
+func PrintHistoryRun(run Run) error {
+	fmt.Println("Run:     ", run.ID)
+	fmt.Println("Case:    ", MaskSecrets(run.Case))
+	fmt.Println("Time:    ", run.Time.Local().Format(time.RFC1123))
+	if run.Commit != "" {
+		fmt.Println("Commit:  ", run.Commit)
+	}
+	fmt.Println("Version: ", run.Version.Version, run.Version.GitCommit, run.Version.GoVersion, run.Version.Platform)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"STATUS", "REQUESTS", "RPS", "MEAN", "P50", "P90", "P95", "P99"})
+	table.SetAutoFormatHeaders(false)
+	report := run.Result
+	for _, status := range report.Statuses {
+		table.Append(append([]string{strconv.Itoa(status.Status), strconv.Itoa(status.Requests),
+			formatRate(status.RPS), formatTime(status.Mean)}, percentileCells(status.Percentiles)...))
+	}
+	table.SetFooter(append([]string{"ALL", strconv.Itoa(report.Requests), formatRate(report.RPS),
+		formatTime(report.Mean)}, percentileCells(report.Percentiles)...))
+	table.Render()
+	fmt.Println("ELAPSED", formatTime(report.Elapsed), "ERRORS", formatRatio(report.ErrorRate))
+
+	var config bytes.Buffer
+	if err := json.Indent(&config, run.Config, "", "  "); err != nil {
+		return err
+	}
+	fmt.Println(config.String())
+	return nil
+}
+
+// PrintHistoryTrend outputs the p95 time and the requests per second of
+// the runs of a case as ASCII charts, a column per run, oldest first.
+// Only the last HistoryTrendRuns runs are drawn
+func PrintHistoryTrend(runs []Run) {
+	if len(runs) == 0 {
+		fmt.Println("No run")
+		return
+	}
+	if len(runs) > HistoryTrendRuns {
+		runs = runs[len(runs)-HistoryTrendRuns:]
+	}
+	p95s := make([]float64, len(runs))
+	rates := make([]float64, len(runs))
+	for i, run := range runs {
+		p95s[i], rates[i] = run.Result.Percentiles.P95, run.Result.RPS
+	}
+	first, last := runs[0].Time.Local().Format("2006-01-02"), runs[len(runs)-1].Time.Local().Format("2006-01-02")
+	fmt.Printf("Case: %s, %d runs from %s to %s\n", MaskSecrets(runs[0].Case), len(runs), first, last)
+	fmt.Println("\nP95")
+	fmt.Print(trendChart(p95s, HistoryTrendHeight, formatTime))
+	fmt.Println("\nRPS")
+	fmt.Print(trendChart(rates, HistoryTrendHeight, formatRate))
+}
+
+// historyRow describes a run in a list
+func historyRow(run Run) []string {
+	commit := run.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	return []string{
+		run.ID,
+		run.Time.Local().Format("2006-01-02 15:04"),
+		MaskSecrets(run.Case),
+		commit,
+		run.Version.Version,
+		strconv.Itoa(run.Result.Requests),
+		formatRate(run.Result.RPS),
+		formatTime(run.Result.Percentiles.P95),
+		formatRatio(run.Result.ErrorRate),
+	}
+}
+
+// percentileCells formats percentiles as table cells
+func percentileCells(p Percentiles) []string {
+	return []string{formatTime(p.P50), formatTime(p.P90), formatTime(p.P95), formatTime(p.P99)}
+}
+
+// PrintConfigList outputs the entries of a config file along with their
+// load settings, without running them
+func PrintConfigList(configs []Config) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"NAME", "METHOD", "URL", "ATTEMPTS", "CONCURRENT", "TAGS"})
+	table.SetAutoFormatHeaders(false)
+
+	for _, c := range configs {
+		table.Append(configListRow(c))
+	}
+	table.Render()
+}
+
+// PrintConfigDocument outputs configs and their environments, if any,
+// as a JSON config document, such as the entries of an import, leaving
+// out the fields they lack
+func PrintConfigDocument(configs []Config, environments map[string]Environment) error {
+	cases := make([]map[string]interface{}, len(configs))
+	for i, c := range configs {
+		raw, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, &cases[i]); err != nil {
+			return err
+		}
+		for key, value := range cases[i] {
+			if value == "" {
+				delete(cases[i], key)
+			}
+		}
+	}
+	doc := map[string]interface{}{"cases": cases}
+	if len(environments) > 0 {
+		doc["environments"] = environments
+	}
+	raw, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(raw))
+	return nil
+}
+
+// PrintCurlExport outputs the curl command of every Config, after a
+// comment naming it. Warnings are comments too, so the output remains
+// a runnable script
+func PrintCurlExport(configs []Config) {
+	for i, c := range configs {
+		if i > 0 {
+			fmt.Println()
+		}
+		if c.Name != "" {
+			fmt.Println("# " + c.Name)
+		}
+		command, warnings := ExportCurl(c)
+		for _, warning := range warnings {
+			fmt.Println("# warning: " + warning)
+		}
+		fmt.Println(command)
+	}
+}
+
+// configListRow describes a Config in a list. Scenarios and traffic
+// mixes show their size instead of a method, and their first URL
+func configListRow(c Config) []string {
+	method, target := c.Method, c.URL
+	if len(c.Steps) > 0 {
+		method, target = fmt.Sprintf("%d STEPS", len(c.Steps)), c.Steps[0].URL
+	} else if len(c.Mix) > 0 {
+		method, target = fmt.Sprintf("MIX OF %d", len(c.Mix)), c.Mix[0].URL
+	}
+	attempts, concurrent := c.Attempts, c.ConcurrentAttempts
+	if attempts == 0 {
+		attempts = DefaultAttempts
+	}
+	if concurrent == 0 {
+		concurrent = DefaultConcurrentAttempts
+	}
+	return []string{
+		MaskSecrets(c.Name),
+		method,
+		MaskSecrets(target),
+		strconv.Itoa(attempts),
+		strconv.Itoa(concurrent),
+		strings.Join(c.Tags, ","),
+	}
 }
 
 func formatTime(time float64) (output string) {
 	output = fmt.Sprintf("%.2f", time) + "s"
 	return
 }
+
+func formatRate(rate float64) (output string) {
+	output = fmt.Sprintf("%.2f", rate) + "/s"
+	return
+}
+
+func formatRatio(ratio float64) (output string) {
+	output = fmt.Sprintf("%.2f", ratio*100) + "%"
+	return
+}
@@ -0,0 +1,188 @@
+package call
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordOptions tune how recorded requests become Config entries
+type RecordOptions struct {
+	Scenario bool // a single scenario with a step per request, instead of an entry per request
+}
+
+// A Recorder is a reverse proxy to a target that records the requests
+// it forwards along with the status of their responses, to replay them
+type Recorder struct {
+	target  *url.URL
+	options RecordOptions
+	proxy   *httputil.ReverseProxy
+
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// recordWriter keeps the status of a proxied response
+type recordWriter struct {
+	http.ResponseWriter
+	status int
+	failed bool // the target could not be reached
+}
+
+func (w *recordWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets the proxy flush streamed responses
+func (w *recordWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// NewRecorder creates a Recorder forwarding requests to target, an
+// http or https URL whose path prefixes the paths of the requests
+func NewRecorder(target string, options RecordOptions) (*Recorder, error) {
+	targetURL, err := parseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		if w, ok := w.(*recordWriter); ok {
+			w.failed = true
+		}
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+	return &Recorder{target: targetURL, options: options, proxy: proxy}, nil
+}
+
+// ServeHTTP forwards a request to the target and records it. Requests
+// the target did not answer are not recorded
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry := harEntry{Request: harRequest{
+		Method: req.Method,
+		URL:    strings.TrimSuffix(r.target.String(), "/") + req.URL.RequestURI(),
+	}}
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			entry.Request.Headers = append(entry.Request.Headers, harPair{Name: name, Value: value})
+		}
+	}
+	if len(body) > 0 {
+		entry.Request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: string(body)}
+	}
+
+	started := time.Now()
+	writer := &recordWriter{ResponseWriter: w}
+	r.proxy.ServeHTTP(writer, req)
+	if writer.failed {
+		return
+	}
+	entry.StartedDateTime = started.Format(time.RFC3339Nano)
+	entry.Time = float64(time.Since(started)) / float64(time.Millisecond)
+	entry.Response.Status = writer.status
+	entry.Response.Content.MimeType = writer.Header().Get("Content-Type")
+
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+}
+
+// Configs returns the recorded requests as Config entries, or as a
+// scenario with the Scenario option, the same way ImportHAR does. Each
+// request checks its response has the recorded status
+func (r *Recorder) Configs() []Config {
+	r.mu.Lock()
+	entries := append([]harEntry(nil), r.entries...)
+	r.mu.Unlock()
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// entries are recorded as responses end, configs follow requests
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].started().Before(entries[j].started())
+	})
+	configs := harConfigs("Recorded session", entries, r.options.Scenario)
+	for i, entry := range entries {
+		check := Check{Type: CheckStatus, Status: []int{entry.Response.Status}}
+		if r.options.Scenario {
+			configs[0].Steps[i].Checks = []Check{check}
+		} else {
+			configs[i].Checks = []Check{check}
+		}
+	}
+	return configs
+}
+
+// Record runs a Recorder of target listening on the listen address,
+// such as :8080, until stop is closed, then waits for the requests
+// being forwarded and returns the recorded Config entries
+func Record(listen, target string, options RecordOptions, stop <-chan struct{}) ([]Config, error) {
+	recorder, err := NewRecorder(target, options)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{Handler: recorder}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return nil, err
+	case <-stop:
+		if err := server.Shutdown(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	configs := recorder.Configs()
+	if len(configs) == 0 {
+		return nil, ErrNothingRecorded
+	}
+	return configs, nil
+}
+
+// parseTarget parses the URL of a target, which must be http or https
+func parseTarget(target string) (*url.URL, error) {
+	targetURL, err := url.Parse(target)
+	if err != nil || targetURL.Scheme != "http" && targetURL.Scheme != "https" || targetURL.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTarget, target)
+	}
+	return targetURL, nil
+}
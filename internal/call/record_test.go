@@ -0,0 +1,153 @@
+package call
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newRecordTarget returns a server answering /missing with a 404 and
+// echoing the body of other requests
+func newRecordTarget(t *testing.T) *httptest.Server {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(append([]byte(r.Method+" "), body...))
+	}))
+	t.Cleanup(target.Close)
+	return target
+}
+
+func TestRecorder(t *testing.T) {
+	target := newRecordTarget(t)
+	recorder, err := NewRecorder(target.URL+"/api/", RecordOptions{})
+	assert.Nil(t, err)
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, proxy.URL+"/users?page=2", nil)
+	req.Header.Set("Cookie", "sid=abc")
+	req.Header.Set("Accept", "application/json")
+	response, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	response.Body.Close()
+
+	response, err = http.Post(proxy.URL+"/users", "application/json", strings.NewReader(`{"name": "ana"}`))
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	assert.Equal(t, `POST {"name": "ana"}`, string(body))
+
+	response, err = http.Get(proxy.URL + "/missing")
+	assert.Nil(t, err)
+	response.Body.Close()
+
+	configs := recorder.Configs()
+	assert.Len(t, configs, 3)
+	users := configs[0]
+	assert.Equal(t, "GET /api/users", users.Name)
+	assert.Equal(t, target.URL+"/api/users?page=2", users.URL)
+	assert.Equal(t, "sid=abc", users.Cookie)
+	assert.Equal(t, []string{"application/json"}, users.Header["Accept"])
+	assert.Equal(t, []Check{{Type: CheckStatus, Status: []int{http.StatusOK}}}, users.Checks)
+
+	create := configs[1]
+	assert.Equal(t, http.MethodPost, create.Method)
+	assert.Equal(t, `{"name": "ana"}`, create.Body)
+	assert.Equal(t, []string{"application/json"}, create.Header["Content-Type"])
+	assert.Equal(t, []Check{{Type: CheckStatus, Status: []int{http.StatusNotFound}}}, configs[2].Checks)
+
+	// the recording replays against the target
+	for _, c := range configs {
+		assert.Nil(t, c.CheckDefaults(), c.Name)
+	}
+}
+
+func TestRecorderScenario(t *testing.T) {
+	target := newRecordTarget(t)
+	recorder, err := NewRecorder(target.URL, RecordOptions{Scenario: true})
+	assert.Nil(t, err)
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	for _, path := range []string{"/api/login", "/api/home"} {
+		response, err := http.Get(proxy.URL + path)
+		assert.Nil(t, err)
+		response.Body.Close()
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	configs := recorder.Configs()
+	assert.Len(t, configs, 1)
+	steps := configs[0].Steps
+	assert.Equal(t, "Recorded session", configs[0].Name)
+	assert.Len(t, steps, 2)
+	assert.Equal(t, "GET /api/login", steps[0].Name)
+	assert.True(t, steps[0].ThinkTime.Value >= 0.04, "think time %v", steps[0].ThinkTime.Value)
+	assert.Nil(t, steps[1].ThinkTime)
+	assert.Equal(t, []Check{{Type: CheckStatus, Status: []int{http.StatusOK}}}, steps[1].Checks)
+}
+
+func TestRecorderUnreachableTarget(t *testing.T) {
+	target := newRecordTarget(t)
+	target.Close()
+	recorder, err := NewRecorder(target.URL, RecordOptions{})
+	assert.Nil(t, err)
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	response, err := http.Get(proxy.URL + "/api/users")
+	assert.Nil(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusBadGateway, response.StatusCode)
+	assert.Empty(t, recorder.Configs())
+}
+
+func TestRecord(t *testing.T) {
+	_, err := Record("127.0.0.1:0", "localhost:3000", RecordOptions{}, nil)
+	assert.True(t, errors.Is(err, ErrInvalidTarget))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	listen := listener.Addr().String()
+	listener.Close()
+
+	target := newRecordTarget(t)
+	stop := make(chan struct{})
+	recorded := make(chan []Config)
+	go func() {
+		configs, err := Record(listen, target.URL, RecordOptions{}, stop)
+		assert.Nil(t, err)
+		recorded <- configs
+	}()
+
+	var response *http.Response
+	for i := 0; i < 50; i++ {
+		if response, err = http.Get("http://" + listen + "/api/status"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, err)
+	response.Body.Close()
+	close(stop)
+	configs := <-recorded
+	assert.Len(t, configs, 1)
+	assert.Equal(t, target.URL+"/api/status", configs[0].URL)
+
+	stop = make(chan struct{})
+	close(stop)
+	_, err = Record("127.0.0.1:0", target.URL, RecordOptions{}, stop)
+	assert.True(t, errors.Is(err, ErrNothingRecorded))
+}
@@ -0,0 +1,247 @@
+package call
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayOptions tune how an access log is replayed
+type ReplayOptions struct {
+	Target     string  // URL the logged paths are sent to, such as https://staging.shop.com
+	Speed      float64 // pace of the original traffic, 2 being twice as fast, 0 as fast as possible
+	Concurrent int     // workers sending requests as fast as possible, DefaultConcurrentAttempts when 0
+}
+
+// logLayout is the timestamp layout of combined and common access logs
+const logLayout = "02/Jan/2006:15:04:05 -0700"
+
+// combinedLogPattern matches the lines of nginx and Apache combined and
+// common access logs, capturing the time and the request line
+var combinedLogPattern = regexp.MustCompile(`^\S+ \S+ \S+ \[([^\]]+)\] "([^"]*)"`)
+
+// JSON access log keys, in the order they are looked up
+var (
+	logMethodKeys  = []string{"method", "request_method", "http_method", "verb"}
+	logPathKeys    = []string{"path", "request_uri", "uri", "url", "request_path"}
+	logRequestKeys = []string{"request", "request_line"}
+	logTimeKeys    = []string{"time", "timestamp", "@timestamp", "time_iso8601", "time_local", "ts", "date"}
+)
+
+// logRequest is a request read from an access log
+type logRequest struct {
+	method string
+	path   string // path and query
+	at     time.Time
+}
+
+// Replay sends the requests of an access log, or of the standard input
+// for StdinPath, to a target. Logs are nginx or Apache combined or
+// common logs, or JSON lines. Requests keep their method and path, their
+// bodies not being logged. With a Speed, each request is sent when it
+// came in the original traffic, the time between them divided by Speed,
+// whatever the pace of the responses, and requests logged without a
+// time are skipped. Otherwise Concurrent workers send them as fast as
+// possible. Lines holding no request are skipped and counted by warnings
+func Replay(path string, options ReplayOptions) (result Result, warnings []string, err error) {
+	target, err := parseTarget(options.Target)
+	if err != nil {
+		return result, nil, err
+	}
+	if options.Speed < 0 {
+		return result, nil, fmt.Errorf("%w: %v", ErrInvalidSpeed, options.Speed)
+	}
+	raw, err := readConfigFile(path)
+	if err != nil {
+		return result, nil, err
+	}
+	requests, skipped := parseAccessLog(raw)
+	if skipped > 0 {
+		warnings = append(warnings, fmt.Sprintf("lines holding no request skipped: %d", skipped))
+	}
+	if options.Speed > 0 {
+		// requests without a time have no place in the original traffic
+		timed := requests[:0]
+		for _, request := range requests {
+			if !request.at.IsZero() {
+				timed = append(timed, request)
+			}
+		}
+		if untimed := len(requests) - len(timed); untimed > 0 {
+			warnings = append(warnings, fmt.Sprintf("requests without a time skipped: %d", untimed))
+		}
+		requests = timed
+	}
+	if len(requests) == 0 {
+		return result, warnings, ErrNoLogRequest
+	}
+
+	result = Result{URL: target, name: "Replay", status: make(map[int]StatusCodeBenchmark)}
+	base := strings.TrimSuffix(target.String(), "/")
+	responses := make(chan HTTPResponse)
+	beginning := time.Now()
+	go func() {
+		send := func(request logRequest) HTTPResponse {
+			response, _ := runStep(http.DefaultClient, Step{Method: request.method, URL: base + request.path}, nil)
+			return response
+		}
+		var wg sync.WaitGroup
+		if options.Speed > 0 {
+			sort.SliceStable(requests, func(i, j int) bool {
+				return requests[i].at.Before(requests[j].at)
+			})
+			first := requests[0].at
+			for _, request := range requests {
+				offset := time.Duration(float64(request.at.Sub(first)) / options.Speed)
+				time.Sleep(time.Until(beginning.Add(offset)))
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					responses <- send(request)
+				}()
+			}
+		} else {
+			queue := make(chan logRequest, len(requests))
+			for _, request := range requests {
+				queue <- request
+			}
+			close(queue)
+			workers := options.Concurrent
+			if workers <= 0 {
+				workers = DefaultConcurrentAttempts
+			}
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for request := range queue {
+						responses <- send(request)
+					}
+				}()
+			}
+		}
+		wg.Wait()
+		close(responses)
+	}()
+	for response := range responses {
+		result.record(response.status, response.execution)
+	}
+	result.summarize()
+	result.totalExecution = time.Since(beginning).Seconds()
+	return result, warnings, nil
+}
+
+// parseAccessLog reads the requests of an access log, returning how
+// many lines were skipped, blank lines aside
+func parseAccessLog(raw []byte) (requests []logRequest, skipped int) {
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var request logRequest
+		var ok bool
+		if strings.HasPrefix(line, "{") {
+			request, ok = parseJSONLogLine(line)
+		} else {
+			request, ok = parseCombinedLogLine(line)
+		}
+		if ok && isMethodAllowed(request.method) && strings.HasPrefix(request.path, "/") {
+			requests = append(requests, request)
+		} else {
+			skipped++
+		}
+	}
+	return
+}
+
+// parseCombinedLogLine reads a line of a combined or common log
+func parseCombinedLogLine(line string) (logRequest, bool) {
+	groups := combinedLogPattern.FindStringSubmatch(line)
+	if groups == nil {
+		return logRequest{}, false
+	}
+	request, ok := parseRequestLine(groups[2])
+	request.at, _ = time.Parse(logLayout, groups[1])
+	return request, ok
+}
+
+// parseJSONLogLine reads a line of a JSON access log, its method and
+// path being either fields or a request line
+func parseJSONLogLine(line string) (request logRequest, ok bool) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return request, false
+	}
+	lookup := func(keys []string) interface{} {
+		for _, key := range keys {
+			if value, ok := fields[key]; ok {
+				return value
+			}
+		}
+		return nil
+	}
+	if requestLine, isString := lookup(logRequestKeys).(string); isString {
+		request, ok = parseRequestLine(requestLine)
+	}
+	if method, isString := lookup(logMethodKeys).(string); isString {
+		request.method = strings.ToUpper(method)
+	}
+	if path, isString := lookup(logPathKeys).(string); isString {
+		request.path, ok = requestPath(path), true
+	}
+	request.at = parseLogTime(lookup(logTimeKeys))
+	return request, ok && request.method != ""
+}
+
+// parseRequestLine reads a request line such as GET /path HTTP/1.1
+func parseRequestLine(line string) (logRequest, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return logRequest{}, false
+	}
+	return logRequest{method: strings.ToUpper(fields[0]), path: requestPath(fields[1])}, true
+}
+
+// requestPath returns the path and query of a logged target, which
+// proxies log as absolute URLs
+func requestPath(target string) string {
+	if u, err := url.Parse(target); err == nil && u.IsAbs() {
+		return u.RequestURI()
+	}
+	return target
+}
+
+// parseLogTime reads a logged time: RFC 3339, the layout of combined
+// logs, or seconds or milliseconds since the epoch. The zero time is
+// returned when it cannot be read
+func parseLogTime(value interface{}) time.Time {
+	switch value := value.(type) {
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, logLayout, "2006-01-02 15:04:05"} {
+			if at, err := time.Parse(layout, value); err == nil {
+				return at
+			}
+		}
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+			return parseLogTime(seconds)
+		}
+	case float64:
+		if value > 1e12 {
+			value /= 1000
+		}
+		return time.Unix(0, int64(value*float64(time.Second)))
+	}
+	return time.Time{}
+}
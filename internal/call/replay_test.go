@@ -0,0 +1,124 @@
+package call
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testAccessLog = `203.0.113.7 - - [10/Mar/2025:13:55:36 +0000] "GET /products?page=2 HTTP/1.1" 200 2326 "https://shop.com/" "Mozilla/5.0"
+203.0.113.8 - ana [10/Mar/2025:13:55:37 +0000] "POST /cart HTTP/2.0" 201 12
+not a request
+
+203.0.113.9 - - [10/Mar/2025:13:55:38 +0000] "GET http://shop.com/missing HTTP/1.1" 404 0 "-" "curl/8.0"
+203.0.113.9 - - [10/Mar/2025:13:55:38 +0000] "\x16\x03\x01" 400 0 "-" "-"
+`
+
+// newReplayTarget returns a server answering 404 to /missing, recording
+// the requests it received
+func newReplayTarget(t *testing.T) (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	var requests []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		mu.Unlock()
+		if r.URL.Path == "/staging/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(target.Close)
+	return target, &requests
+}
+
+func TestReplay(t *testing.T) {
+	target, requests := newReplayTarget(t)
+	path := writeConfigFile(t, "access.log", testAccessLog)
+
+	result, warnings, err := Replay(path, ReplayOptions{Target: target.URL + "/staging/", Concurrent: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"lines holding no request skipped: 2"}, warnings)
+	assert.ElementsMatch(t, []string{"GET /staging/products?page=2", "POST /staging/cart", "GET /staging/missing"}, *requests)
+	assert.Equal(t, 2, result.status[http.StatusOK].total)
+	assert.Equal(t, 1, result.status[http.StatusNotFound].total)
+	assert.Equal(t, "Replay", result.GetName())
+}
+
+func TestReplayTiming(t *testing.T) {
+	target, requests := newReplayTarget(t)
+	path := writeConfigFile(t, "access.log", testAccessLog)
+
+	// two seconds of traffic, 20 times faster
+	beginning := time.Now()
+	result, _, err := Replay(path, ReplayOptions{Target: target.URL, Speed: 20})
+	elapsed := time.Since(beginning)
+	assert.Nil(t, err)
+	assert.Len(t, *requests, 3)
+	assert.Equal(t, "GET /products?page=2", (*requests)[0])
+	assert.True(t, elapsed >= 100*time.Millisecond && elapsed < time.Second, "replayed in %v", elapsed)
+	assert.Equal(t, 3, result.status[http.StatusOK].total)
+}
+
+func TestReplayTimingSkipsUntimedRequests(t *testing.T) {
+	target, requests := newReplayTarget(t)
+	path := writeConfigFile(t, "access.log", `{"method": "GET", "path": "/untimed"}
+{"method": "GET", "path": "/a", "time": "2025-03-10T13:55:36Z"}
+{"method": "GET", "path": "/b", "time": "2025-03-10T13:55:37Z"}
+`)
+
+	beginning := time.Now()
+	result, warnings, err := Replay(path, ReplayOptions{Target: target.URL, Speed: 20})
+	assert.Nil(t, err)
+	assert.True(t, time.Since(beginning) < time.Second)
+	assert.Equal(t, []string{"requests without a time skipped: 1"}, warnings)
+	assert.Equal(t, []string{"GET /a", "GET /b"}, *requests)
+	assert.Equal(t, 2, result.status[http.StatusOK].total)
+	assert.True(t, result.GetAvgExecution() < 0.05)
+	assert.True(t, result.GetTotalExecution() >= 0.05)
+
+	_, _, err = Replay(writeConfigFile(t, "untimed.log", `{"method": "GET", "path": "/untimed"}`), ReplayOptions{Target: target.URL, Speed: 1})
+	assert.True(t, errors.Is(err, ErrNoLogRequest))
+}
+
+func TestReplayErrors(t *testing.T) {
+	path := writeConfigFile(t, "access.log", testAccessLog)
+	_, _, err := Replay(path, ReplayOptions{Target: "staging.shop.com"})
+	assert.True(t, errors.Is(err, ErrInvalidTarget))
+	_, _, err = Replay(path, ReplayOptions{Target: "http://localhost", Speed: -1})
+	assert.True(t, errors.Is(err, ErrInvalidSpeed))
+	_, warnings, err := Replay(writeConfigFile(t, "empty.log", "garbage\n"), ReplayOptions{Target: "http://localhost"})
+	assert.True(t, errors.Is(err, ErrNoLogRequest))
+	assert.Equal(t, []string{"lines holding no request skipped: 1"}, warnings)
+}
+
+func TestParseAccessLog(t *testing.T) {
+	at := time.Date(2025, 3, 10, 13, 55, 36, 0, time.UTC)
+	tests := []struct {
+		name string
+		line string
+		want []logRequest
+	}{
+		{name: "common", line: `::1 - - [10/Mar/2025:13:55:36 +0000] "DELETE /items/1 HTTP/1.1" 204 0`, want: []logRequest{{method: "DELETE", path: "/items/1", at: at}}},
+		{name: "json fields", line: `{"time_iso8601": "2025-03-10T13:55:36Z", "request_method": "put", "request_uri": "/items/1?x=y", "status": 200}`, want: []logRequest{{method: "PUT", path: "/items/1?x=y", at: at}}},
+		{name: "json request line", line: `{"ts": 1741614936, "request": "GET /health HTTP/1.1"}`, want: []logRequest{{method: "GET", path: "/health", at: at}}},
+		{name: "json milliseconds", line: `{"@timestamp": "1741614936000", "method": "GET", "url": "https://shop.com/a"}`, want: []logRequest{{method: "GET", path: "/a", at: at}}},
+		{name: "json without method", line: `{"path": "/a"}`},
+		{name: "unknown method", line: `::1 - - [10/Mar/2025:13:55:36 +0000] "PROPFIND /dav HTTP/1.1" 405 0`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, skipped := parseAccessLog([]byte(tt.line))
+			assert.Equal(t, len(tt.want) == 0, skipped == 1)
+			for i := range requests {
+				assert.True(t, tt.want[i].at.Equal(requests[i].at), requests[i].at.String())
+				requests[i].at = tt.want[i].at
+			}
+			assert.Equal(t, tt.want, requests)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package call
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// A ResultReport is the JSON form of a Result, written to be kept and
+// compared with later runs. Times are in seconds
+type ResultReport struct {
+	Name        string         `json:"name,omitempty"`
+	URL         string         `json:"url"`
+	Environment string         `json:"environment,omitempty"`
+	Requests    int            `json:"requests"`
+	Elapsed     float64        `json:"elapsed"`
+	RPS         float64        `json:"rps"`
+	Mean        float64        `json:"mean"`
+	Min         float64        `json:"min"`
+	Max         float64        `json:"max"`
+	Percentiles Percentiles    `json:"percentiles"`
+	ErrorRate   float64        `json:"error_rate"` // share of failed requests and statuses from 400
+	Statuses    []StatusReport `json:"statuses"`
+	Steps       []ResultReport `json:"steps,omitempty"`
+	Endpoints   []ResultReport `json:"endpoints,omitempty"`
+}
+
+// A StatusReport holds the responses of a status code, 0 for the
+// requests that got no response
+type StatusReport struct {
+	Status      int         `json:"status"`
+	Requests    int         `json:"requests"`
+	RPS         float64     `json:"rps"`
+	Mean        float64     `json:"mean"`
+	Percentiles Percentiles `json:"percentiles"`
+}
+
+// Percentiles of the execution times of responses
+type Percentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+// resultsFile is the JSON document holding the reports of a run
+type resultsFile struct {
+	Results []ResultReport `json:"results"`
+}
+
+// NewResultReport summarizes a Result into a ResultReport. The rates
+// are computed over the elapsed time of the result
+func NewResultReport(result Result) ResultReport {
+	report := ResultReport{
+		Name:        MaskSecrets(result.name),
+		Environment: result.environment,
+		Elapsed:     result.totalExecution,
+		Min:         result.minExecution,
+		Max:         result.maxExecution,
+		ErrorRate:   1 - successRatio(result),
+		Statuses:    []StatusReport{},
+	}
+	if result.URL != nil {
+		report.URL = MaskSecrets(result.URL.String())
+	}
+	statuses := make([]int, 0, len(result.status))
+	for status := range result.status {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+
+	var executions []float64
+	var execution float64
+	for _, status := range statuses {
+		benchmark := result.status[status]
+		report.Requests += benchmark.total
+		execution += benchmark.execution
+		executions = append(executions, benchmark.executions...)
+		report.Statuses = append(report.Statuses, StatusReport{
+			Status:      status,
+			Requests:    benchmark.total,
+			RPS:         rate(benchmark.total, result.totalExecution),
+			Mean:        benchmark.execution / float64(benchmark.total),
+			Percentiles: newPercentiles(benchmark.executions),
+		})
+	}
+	if report.Requests == 0 {
+		report.ErrorRate = 0
+	} else {
+		report.Mean = execution / float64(report.Requests)
+	}
+	report.RPS = rate(report.Requests, result.totalExecution)
+	report.Percentiles = newPercentiles(executions)
+
+	for _, step := range result.steps {
+		report.Steps = append(report.Steps, NewResultReport(step))
+	}
+	for _, endpoint := range result.endpoints {
+		report.Endpoints = append(report.Endpoints, NewResultReport(endpoint))
+	}
+	return report
+}
+
+// WriteResultReports writes the reports of results as a JSON document
+// holding them in a results list
+func WriteResultReports(w io.Writer, results []Result) error {
+	file := resultsFile{Results: make([]ResultReport, len(results))}
+	for i, result := range results {
+		file.Results[i] = NewResultReport(result)
+	}
+	raw, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(raw))
+	return err
+}
+
+// ReadResultReports reads the reports written by WriteResultReports
+// from a file, or from the standard input for StdinPath
+func ReadResultReports(path string) ([]ResultReport, error) {
+	raw, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file resultsFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidResults, path, err)
+	}
+	if file.Results == nil {
+		return nil, fmt.Errorf("%w: %s: no results", ErrInvalidResults, path)
+	}
+	return file.Results, nil
+}
+
+// newPercentiles computes the percentiles of execution times, using
+// the nearest rank method
+func newPercentiles(executions []float64) Percentiles {
+	if len(executions) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]float64(nil), executions...)
+	sort.Float64s(sorted)
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		return sorted[max(i, 0)]
+	}
+	return Percentiles{P50: rank(50), P90: rank(90), P95: rank(95), P99: rank(99)}
+}
+
+// rate returns requests per second over elapsed seconds
+func rate(requests int, elapsed float64) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(requests) / elapsed
+}
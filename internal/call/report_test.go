@@ -0,0 +1,66 @@
+package call
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResultReport(t *testing.T) {
+	target, _ := url.Parse("http://localhost/users")
+	result := Result{URL: target, name: "users", status: make(map[int]StatusCodeBenchmark)}
+	for i := 1; i <= 9; i++ {
+		result.record(200, float64(i)/10)
+	}
+	result.record(500, 2)
+	result.totalExecution = 2
+
+	report := NewResultReport(result)
+	assert.Equal(t, "users", report.Name)
+	assert.Equal(t, "http://localhost/users", report.URL)
+	assert.Equal(t, 10, report.Requests)
+	assert.Equal(t, 5.0, report.RPS)
+	assert.InDelta(t, 0.65, report.Mean, 1e-9)
+	assert.Equal(t, 0.1, report.Min)
+	assert.Equal(t, 2.0, report.Max)
+	assert.Equal(t, Percentiles{P50: 0.5, P90: 0.9, P95: 2, P99: 2}, report.Percentiles)
+	assert.InDelta(t, 0.1, report.ErrorRate, 1e-9)
+	assert.Len(t, report.Statuses, 2)
+	assert.Equal(t, 200, report.Statuses[0].Status)
+	assert.Equal(t, 9, report.Statuses[0].Requests)
+	assert.Equal(t, 4.5, report.Statuses[0].RPS)
+	assert.InDelta(t, 0.5, report.Statuses[0].Mean, 1e-9)
+	assert.Equal(t, Percentiles{P50: 0.5, P90: 0.9, P95: 0.9, P99: 0.9}, report.Statuses[0].Percentiles)
+	assert.Equal(t, StatusReport{Status: 500, Requests: 1, RPS: 0.5, Mean: 2, Percentiles: Percentiles{2, 2, 2, 2}}, report.Statuses[1])
+
+	registerSecret("report-t0ken")
+	masked := NewResultReport(Result{name: "users report-t0ken", status: make(map[int]StatusCodeBenchmark)})
+	assert.Equal(t, "users ****", masked.Name)
+
+	empty := NewResultReport(Result{})
+	assert.Equal(t, 0.0, empty.ErrorRate)
+	assert.Equal(t, []StatusReport{}, empty.Statuses)
+}
+
+func TestResultReportsRoundTrip(t *testing.T) {
+	target, _ := url.Parse("http://localhost/")
+	result := Result{URL: target, status: make(map[int]StatusCodeBenchmark), steps: []Result{{name: "login", URL: target}}}
+	result.record(204, 0.25)
+	result.totalExecution = 0.5
+
+	var out bytes.Buffer
+	assert.Nil(t, WriteResultReports(&out, []Result{result}))
+	path := writeConfigFile(t, "results.json", out.String())
+	reports, err := ReadResultReports(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []ResultReport{NewResultReport(result)}, reports)
+	assert.Equal(t, "login", reports[0].Steps[0].Name)
+
+	_, err = ReadResultReports(writeConfigFile(t, "config.json", `[{"url": "http://localhost"}]`))
+	assert.True(t, errors.Is(err, ErrInvalidResults))
+	_, err = ReadResultReports(writeConfigFile(t, "other.json", `{"cases": []}`))
+	assert.True(t, errors.Is(err, ErrInvalidResults))
+}
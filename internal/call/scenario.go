@@ -0,0 +1,288 @@
+package call
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// Supported sources for a response Extraction
+const (
+	ExtractFromJSON   = "json"
+	ExtractFromRegex  = "regex"
+	ExtractFromHeader = "header"
+	ExtractFromCookie = "cookie"
+)
+
+// A Step is a single request of a scenario. Every virtual user runs
+// the steps in order, and URL, Body and Header values may reference
+// variables extracted by previous steps using the {{name}} syntax.
+// ThinkTime, when set, replaces the think time of the Config after
+// this step
+type Step struct {
+	Name      string              `json:"name"`
+	Method    string              `json:"method"`
+	URL       string              `json:"url"`
+	Body      string              `json:"body,omitempty"`
+	Header    map[string][]string `json:"header,omitempty"`
+	ThinkTime *ThinkTime          `json:"think_time,omitempty"`
+	Extract   []Extraction        `json:"extract,omitempty"`
+	Checks    []Check             `json:"checks,omitempty"`
+}
+
+// An Extraction captures a value from a step response into a variable
+// that later steps of the same virtual user can use
+type Extraction struct {
+	Var  string `json:"var"`  // variable name
+	From string `json:"from"` // json, regex, header or cookie
+	Expr string `json:"expr"` // JSON path, regular expression, header or cookie name
+
+	pattern *regexp.Regexp // compiled Expr of a regex extraction
+}
+
+// stepResponse is the outcome of a single step execution
+type stepResponse struct {
+	HTTPResponse
+	step int
+}
+
+// flowResponse is the outcome of a whole scenario iteration
+type flowResponse struct {
+	steps     []stepResponse
+	execution float64 // total execution time
+	failed    bool    // the flow stopped before its last step
+}
+
+var variablePattern = regexp.MustCompile(`{{\s*([\w.-]+)\s*}}`)
+
+// checkSteps validates every step of a scenario
+func checkSteps(steps []Step) (errs []fieldError) {
+	for i := range steps {
+		step := &steps[i]
+		field := strconv.Itoa(i)
+		if step.Method == "" {
+			step.Method = http.MethodGet
+		}
+		if !isMethodAllowed(step.Method) {
+			errs = append(errs, fieldError{field + ".method", ErrMethodNotAllowed})
+		}
+		if step.URL == "" {
+			errs = append(errs, fieldError{field + ".url", ErrEmptyStepURL})
+		}
+		if step.ThinkTime != nil {
+			if err := step.ThinkTime.check(); err != nil {
+				errs = append(errs, fieldError{field + ".think_time", err})
+			}
+		}
+		errs = append(errs, prefixErrors(field+".checks", checkChecks(step.Checks))...)
+		for j := range step.Extract {
+			extraction := &step.Extract[j]
+			extractionField := fmt.Sprintf("%s.extract.%d", field, j)
+			switch extraction.From {
+			case ExtractFromJSON, ExtractFromHeader, ExtractFromCookie:
+			case ExtractFromRegex:
+				pattern, err := regexp.Compile(extraction.Expr)
+				if err != nil {
+					errs = append(errs, fieldError{extractionField + ".expr", err})
+				}
+				extraction.pattern = pattern
+			default:
+				errs = append(errs, fieldError{extractionField + ".from", fmt.Errorf("%w: %q", ErrInvalidExtraction, extraction.From)})
+			}
+		}
+	}
+	return
+}
+
+// makeScenario runs the scenario steps with one virtual user per client
+// until Attempts iterations were executed, feeding result with one
+// entry per flow and one Result per step
+func (call *ConcurrentCall) makeScenario(result *Result, clients []*http.Client) {
+	steps := call.config.Steps
+	result.steps = make([]Result, len(steps))
+	for i, step := range steps {
+		stepURL, _ := url.Parse(step.URL)
+		result.steps[i] = Result{
+			URL:    stepURL,
+			name:   step.Name,
+			status: make(map[int]StatusCodeBenchmark),
+			checks: newCheckBenchmarks(step.Checks),
+		}
+	}
+
+	iterations := make(chan struct{}, call.Attempts)
+	for i := 0; i < call.Attempts; i++ {
+		iterations <- struct{}{}
+	}
+	close(iterations)
+
+	flows := make(chan flowResponse)
+	var wg sync.WaitGroup
+	wg.Add(len(clients))
+	for _, client := range clients {
+		go func() {
+			defer wg.Done()
+			vars := make(map[string]string)
+			for range iterations {
+				beginning := time.Now()
+				flows <- runFlow(client, steps, vars, call.config.ThinkTime)
+				pace(beginning, call.config.Pacing)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(flows)
+	}()
+
+	for flow := range flows {
+		last := flow.steps[len(flow.steps)-1]
+		result.record(last.status, flow.execution)
+		if flow.failed {
+			result.failedFlows++
+		}
+		for _, response := range flow.steps {
+			result.steps[response.step].record(response.status, response.execution)
+			result.steps[response.step].recordChecks(response.checks)
+		}
+	}
+	for i := range result.steps {
+		result.steps[i].summarize()
+	}
+	call.Attempts = 0
+}
+
+// runFlow executes all steps once, in order, stopping at the first
+// step that fails or whose extractions cannot be satisfied. The flow
+// execution time is the sum of its steps, leaving think time out
+func runFlow(client *http.Client, steps []Step, vars map[string]string, thinkTime *ThinkTime) (flow flowResponse) {
+	for i, step := range steps {
+		response, err := runStep(client, step, vars)
+		flow.steps = append(flow.steps, stepResponse{HTTPResponse: response, step: i})
+		flow.execution += response.execution
+		if err != nil || response.err != nil || response.status >= http.StatusBadRequest {
+			flow.failed = true
+			return
+		}
+		if i < len(steps)-1 {
+			think(step.thinkTime(thinkTime))
+		}
+	}
+	return
+}
+
+// thinkTime returns the think time after the step, fallback when it
+// has none of its own
+func (s Step) thinkTime(fallback *ThinkTime) *ThinkTime {
+	if s.ThinkTime != nil {
+		return s.ThinkTime
+	}
+	return fallback
+}
+
+// runStep executes a single step, extracting the configured
+// variables from its response into vars
+func runStep(client *http.Client, step Step, vars map[string]string) (response HTTPResponse, err error) {
+	req, err := http.NewRequest(step.Method, interpolate(step.URL, vars), strings.NewReader(interpolate(step.Body, vars)))
+	if err != nil {
+		return HTTPResponse{err: err, status: http.StatusBadRequest}, err
+	}
+	for key, values := range step.Header {
+		for _, value := range values {
+			req.Header.Add(key, interpolate(value, vars))
+		}
+	}
+
+	beginning := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		execution := time.Since(beginning).Seconds()
+		return HTTPResponse{
+			err:       err,
+			execution: execution,
+			status:    http.StatusRequestTimeout,
+			checks:    evaluateChecks(step.Checks, nil, nil, execution),
+		}, nil
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	execution := time.Since(beginning).Seconds()
+	response = HTTPResponse{
+		err:       err,
+		execution: execution,
+		status:    resp.StatusCode,
+		checks:    evaluateChecks(step.Checks, resp, body, execution),
+	}
+	if err != nil {
+		return
+	}
+
+	for _, extraction := range step.Extract {
+		value, ok := extract(extraction, resp, body)
+		if !ok {
+			return response, fmt.Errorf("%w: %s", ErrExtractionFailed, extraction.Var)
+		}
+		vars[extraction.Var] = value
+	}
+	return
+}
+
+// extract reads the value described by extraction from a response
+func extract(extraction Extraction, resp *http.Response, body []byte) (string, bool) {
+	switch extraction.From {
+	case ExtractFromJSON:
+		value := gjson.GetBytes(body, extraction.Expr)
+		return value.String(), value.Exists()
+	case ExtractFromRegex:
+		matches := compiled(extraction.pattern, extraction.Expr).FindSubmatch(body)
+		if matches == nil {
+			return "", false
+		}
+		return string(matches[len(matches)-1]), true
+	case ExtractFromHeader:
+		values := resp.Header.Values(extraction.Expr)
+		if len(values) == 0 {
+			return "", false
+		}
+		return values[0], true
+	case ExtractFromCookie:
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == extraction.Expr {
+				return cookie.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// interpolate replaces every {{name}} occurrence in s by its variable
+// value. Unknown variables are left untouched
+func interpolate(s string, vars map[string]string) string {
+	if len(vars) == 0 || !strings.Contains(s, "{{") {
+		return s
+	}
+	return variablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := variablePattern.FindStringSubmatch(match)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// compiled returns pattern, compiled from expr when the check or
+// extraction holding it was not validated
+func compiled(pattern *regexp.Regexp, expr string) *regexp.Regexp {
+	if pattern != nil {
+		return pattern
+	}
+	return regexp.MustCompile(expr)
+}
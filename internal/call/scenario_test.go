@@ -0,0 +1,144 @@
+package call
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func scenarioConfig() Config {
+	return Config{
+		Name: "checkout",
+		Steps: []Step{
+			{
+				Name:   "login",
+				Method: http.MethodPost,
+				URL:    "http://www.foo.com/login",
+				Extract: []Extraction{
+					{Var: "token", From: ExtractFromJSON, Expr: "auth.token"},
+					{Var: "session", From: ExtractFromHeader, Expr: "X-Session"},
+				},
+			},
+			{
+				Name:   "profile",
+				Method: http.MethodGet,
+				URL:    "http://www.foo.com/profile/{{session}}",
+				Header: map[string][]string{"Authorization": {"Bearer {{token}}"}},
+			},
+		},
+	}
+}
+
+func registerScenarioResponders() {
+	httpmock.RegisterResponder("POST", "http://www.foo.com/login",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `{"auth": {"token": "abc"}}`)
+			resp.Header.Set("X-Session", "42")
+			return resp, nil
+		})
+	httpmock.RegisterResponder("GET", "http://www.foo.com/profile/42",
+		func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Authorization") != "Bearer abc" {
+				return httpmock.NewStringResponse(401, ``), nil
+			}
+			return httpmock.NewStringResponse(200, `{}`), nil
+		})
+}
+
+func TestMakeScenarioChainsExtractedValues(test *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerScenarioResponders()
+
+	config := scenarioConfig()
+	assert.Nil(test, config.CheckDefaults())
+	call := ConcurrentCall{Attempts: 20, ConcurrentAttempts: 5}
+	call.URL, _ = url.Parse(config.Steps[0].URL)
+	call.SetConfig(config)
+	result := call.MakeIt()
+
+	assert.Equal(test, 20, result.status[200].total)
+	assert.Equal(test, 0, result.failedFlows)
+	assert.Equal(test, 2, len(result.steps))
+	assert.Equal(test, "login", result.steps[0].GetName())
+	assert.Equal(test, 20, result.steps[0].status[200].total)
+	assert.Equal(test, 20, result.steps[1].status[200].total)
+}
+
+func TestMakeScenarioStopsFlowWhenExtractionFails(test *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", "http://www.foo.com/login",
+		httpmock.NewStringResponder(200, `{}`))
+
+	config := scenarioConfig()
+	assert.Nil(test, config.CheckDefaults())
+	call := ConcurrentCall{Attempts: 10, ConcurrentAttempts: 2}
+	call.URL, _ = url.Parse(config.Steps[0].URL)
+	call.SetConfig(config)
+	result := call.MakeIt()
+
+	assert.Equal(test, 10, result.failedFlows)
+	assert.Equal(test, 10, result.steps[0].status[200].total)
+	assert.Equal(test, 0, len(result.steps[1].status))
+}
+
+func TestExtract(test *testing.T) {
+	resp := httpmock.NewStringResponse(200, ``)
+	resp.Header.Set("Location", "/orders/7")
+	resp.Header.Add("Set-Cookie", "sid=xyz; Path=/")
+	body := []byte(`<input name="csrf" value="s3cr3t">`)
+
+	tests := []struct {
+		name       string
+		extraction Extraction
+		want       string
+		wantOk     bool
+	}{
+		{"regex group", Extraction{From: ExtractFromRegex, Expr: `value="(\w+)"`}, "s3cr3t", true},
+		{"regex without match", Extraction{From: ExtractFromRegex, Expr: `token=(\w+)`}, "", false},
+		{"header", Extraction{From: ExtractFromHeader, Expr: "Location"}, "/orders/7", true},
+		{"cookie", Extraction{From: ExtractFromCookie, Expr: "sid"}, "xyz", true},
+		{"missing cookie", Extraction{From: ExtractFromCookie, Expr: "other"}, "", false},
+		{"json on html", Extraction{From: ExtractFromJSON, Expr: "id"}, "", false},
+	}
+	for _, tt := range tests {
+		test.Run(tt.name, func(t *testing.T) {
+			got, ok := extract(tt.extraction, resp, body)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInterpolate(test *testing.T) {
+	vars := map[string]string{"id": "7", "token": "abc"}
+	assert.Equal(test, "/orders/7?t=abc", interpolate("/orders/{{id}}?t={{ token }}", vars))
+	assert.Equal(test, "/orders/{{missing}}", interpolate("/orders/{{missing}}", vars))
+}
+
+func TestCheckStepsRejectsInvalidSteps(test *testing.T) {
+	assert.NotNil(test, checkSteps([]Step{{Method: "ASHE", URL: "http://www.foo.com"}}))
+	assert.NotNil(test, checkSteps([]Step{{Method: http.MethodGet}}))
+	assert.NotNil(test, checkSteps([]Step{{URL: "http://www.foo.com", Extract: []Extraction{{From: "xpath"}}}}))
+	assert.NotNil(test, checkSteps([]Step{{URL: "http://www.foo.com", ThinkTime: &ThinkTime{Distribution: DistributionFixed, Value: -1}}}))
+
+	steps := []Step{{URL: "http://www.foo.com", Extract: []Extraction{{Var: "id", From: ExtractFromRegex, Expr: `id=(\d+)`}}}}
+	assert.Nil(test, checkSteps(steps))
+	assert.Equal(test, http.MethodGet, steps[0].Method)
+	assert.NotNil(test, steps[0].Extract[0].pattern)
+	got, ok := extract(steps[0].Extract[0], nil, []byte("id=42"))
+	assert.True(test, ok)
+	assert.Equal(test, "42", got)
+}
+
+func TestStepThinkTime(test *testing.T) {
+	fallback := &ThinkTime{Distribution: DistributionFixed, Value: 1}
+	own := &ThinkTime{Distribution: DistributionFixed, Value: 2}
+	assert.Equal(test, fallback, Step{}.thinkTime(fallback))
+	assert.Equal(test, own, Step{ThinkTime: own}.thinkTime(fallback))
+	assert.Equal(test, own, MixEntry{Step: Step{ThinkTime: own}}.thinkTime(nil))
+}
@@ -0,0 +1,229 @@
+package call
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// schemaField documents a config field in the JSON Schema
+type schemaField struct {
+	description string
+	enum        []string
+	def         interface{}
+	required    bool
+	ref         string // definition replacing the schema of the Go type
+}
+
+// schemaFields documents every config field, keyed by the struct
+// declaring it and its JSON name. Fields missing here make the schema
+// tests fail, so new blocks get documented as they are added
+var schemaFields = map[string]schemaField{
+	"Config.name":           {description: "Name of the request in reports"},
+	"Config.tags":           {description: "Labels selecting the entry with tag filters"},
+	"Config.group":          {description: "Group of the entry in parallel suites, groups running one after another"},
+	"Config.func":           {description: "Go code whose result replaces the %s verb of the body"},
+	"Config.method":         {description: "HTTP method, ignored with steps or mix", enum: methodNames()},
+	"Config.attempts":       {description: "Total number of requests", def: DefaultAttempts},
+	"Config.concurrent":     {description: "Number of workers sending requests at the same time", def: DefaultConcurrentAttempts},
+	"Config.url":            {description: "Requested URL, ignored with steps or mix"},
+	"Config.body":           {description: "Request body"},
+	"Config.header":         {description: "Request headers, each with a list of values"},
+	"Config.host":           {description: "Unused"},
+	"Config.form":           {description: "Unused"},
+	"Config.postform":       {description: "Unused"},
+	"Config.cookie":         {description: "Cookie header seeding the cookie jars, as name=value pairs separated by semicolons"},
+	"Config.cookie_jar":     {description: "How cookies set by responses are kept: none, one jar per worker or one jar for all workers. Defaults to isolated when cookie is set, none otherwise, and cannot be none when cookie is set", enum: []string{CookieJarNone, CookieJarIsolated, CookieJarShared}},
+	"Config.auth":           {description: "Credentials added to every request"},
+	"Config.insecure":       {description: "Whether TLS certificates are accepted without verification"},
+	"Config.proxy":          {description: "Proxy every request goes through, as [scheme://][user:password@]host[:port], http being the default scheme"},
+	"Config.resolve":        {description: "Addresses dialed instead of resolving hosts, each as host:port:address"},
+	"Config.http_version":   {description: "HTTP version spoken, HTTP/2 being negotiated over TLS by default", enum: []string{HTTPVersion11, HTTPVersion2}},
+	"Config.think_time":     {description: "Seconds a worker waits after each request, either a number or a distribution"},
+	"Config.pacing":         {description: "Minimum seconds between the start of two iterations of a worker"},
+	"Config.checks":         {description: "Assertions evaluated against every response"},
+	"Config.steps":          {description: "Scenario of requests run in order by every virtual user"},
+	"Config.mix":            {description: "Weighted requests sharing the workers, each request picking one entry"},
+	"document.defaults":     {description: "Fields inherited by every entry lacking them", ref: "Config"},
+	"document.include":      {description: "Config files, or globs, whose entries come before the cases, relative to this file"},
+	"document.environments": {description: "Targets the entries run against, selected by name"},
+	"document.cases":        {description: "Requests to run"},
+	"document.suite":        {description: "How the entries run"},
+	"Suite.parallel":        {description: "Run the entries at the same time, or group after group when they have one"},
+	"Suite.max_concurrency": {description: "Maximum requests in flight across all entries, unlimited when 0"},
+	"Environment.variables": {description: "Values of ${VAR} references, looked up before the process environment"},
+	"Environment.override":  {description: "Fields deep-merged into every entry", ref: "Config"},
+	"Step.name":             {description: "Name of the step in reports"},
+	"Step.method":           {description: "HTTP method", enum: methodNames(), def: "GET"},
+	"Step.url":              {description: "Requested URL, which may hold {{variables}}", required: true},
+	"Step.body":             {description: "Request body, which may hold {{variables}}"},
+	"Step.header":           {description: "Request headers, each with a list of values, which may hold {{variables}}"},
+	"Step.think_time":       {description: "Seconds to wait after the step instead of the think time of the entry"},
+	"Step.extract":          {description: "Values saved from the response as variables for the next steps"},
+	"Step.checks":           {description: "Assertions evaluated against every response of the step"},
+	"MixEntry.weight":       {description: "Relative share of the requests going to this entry", def: 1},
+	"Extraction.var":        {description: "Name of the variable", required: true},
+	"Extraction.from":       {description: "Where the value is read from", enum: []string{ExtractFromJSON, ExtractFromRegex, ExtractFromHeader, ExtractFromCookie}, required: true},
+	"Extraction.expr":       {description: "JSON path, regular expression with a capture group, header or cookie name", required: true},
+	"Check.name":            {description: "Name of the check in reports"},
+	"Check.type":            {description: "What the check asserts", enum: []string{CheckStatus, CheckBodyContains, CheckBodyRegex, CheckJSONPath, CheckHeader, CheckMaxBodySize, CheckMaxResponseTime}, required: true},
+	"Check.expr":            {description: "Substring, regular expression, JSON path or header name"},
+	"Check.equals":          {description: "Expected value at the JSON path"},
+	"Check.status":          {description: "Accepted status codes"},
+	"Check.max":             {description: "Maximum body size in bytes or response time in seconds"},
+	"Auth.type":             {description: "Authentication scheme", enum: []string{AuthBasic, AuthBearer, AuthOAuth2, AuthHMAC, AuthSigV4}, required: true},
+	"Auth.username":         {description: "Basic auth user name"},
+	"Auth.password":         {description: "Basic auth password"},
+	"Auth.token":            {description: "Bearer token"},
+	"Auth.token_url":        {description: "OAuth2 client credentials token endpoint"},
+	"Auth.client_id":        {description: "OAuth2 client id"},
+	"Auth.client_secret":    {description: "OAuth2 client secret"},
+	"Auth.scopes":           {description: "OAuth2 scopes"},
+	"Auth.key_id":           {description: "HMAC key id or SigV4 access key"},
+	"Auth.secret":           {description: "HMAC or SigV4 secret key"},
+	"Auth.header":           {description: "Header holding the HMAC signature", def: "Authorization"},
+	"Auth.region":           {description: "SigV4 region"},
+	"Auth.service":          {description: "SigV4 service"},
+	"Auth.session_token":    {description: "SigV4 session token of temporary credentials"},
+	"ThinkTime.distribution": {
+		description: "Distribution of the think time samples",
+		enum:        []string{DistributionFixed, DistributionUniform, DistributionNormal, DistributionExponential},
+		required:    true,
+	},
+	"ThinkTime.value":  {description: "Seconds of a fixed think time"},
+	"ThinkTime.min":    {description: "Minimum seconds of a uniform think time"},
+	"ThinkTime.max":    {description: "Maximum seconds of a uniform think time"},
+	"ThinkTime.mean":   {description: "Mean seconds of a normal or exponential think time"},
+	"ThinkTime.stddev": {description: "Standard deviation in seconds of a normal think time"},
+}
+
+// methodNames returns the allowed methods, sorted
+func methodNames() (methods []string) {
+	for method := range allowedMethods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return
+}
+
+// ConfigSchema generates the JSON Schema of config files from the
+// Config struct, so editors can complete and validate them
+func ConfigSchema() ([]byte, error) {
+	definitions := map[string]interface{}{
+		"SecretFile": map[string]interface{}{
+			"type":        "object",
+			"description": "Content of a file, such as a mounted secret, masked in every output",
+			"properties": map[string]interface{}{
+				secretFileKey: map[string]interface{}{"type": "string", "description": "Path of the file"},
+			},
+			"required":             []string{secretFileKey},
+			"additionalProperties": false,
+		},
+	}
+	schema := map[string]interface{}{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"title":   "call-it config",
+		"anyOf": []interface{}{
+			typeSchema(reflect.TypeOf([]Config{}), definitions),
+			typeSchema(reflect.TypeOf(document{}), definitions),
+		},
+		"definitions": definitions,
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// typeSchema returns the schema of a Go type. Structs are added to the
+// definitions and referenced
+func typeSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem(), definitions)
+	case reflect.Struct:
+		if _, ok := definitions[t.Name()]; !ok {
+			definitions[t.Name()] = nil // guards against recursive types
+			definitions[t.Name()] = structSchema(t, definitions)
+		}
+		ref := map[string]interface{}{"$ref": "#/definitions/" + t.Name()}
+		if t == reflect.TypeOf(ThinkTime{}) {
+			return map[string]interface{}{"anyOf": []interface{}{
+				map[string]interface{}{"type": "number", "minimum": 0},
+				ref,
+			}}
+		}
+		return ref
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), definitions)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), definitions)}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+	return map[string]interface{}{"type": "string"}
+}
+
+// structSchema returns the object schema of a struct, inlining the
+// fields of embedded structs. Unknown keys are rejected, as they are
+// most likely typos
+func structSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Anonymous {
+				collect(field.Type)
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" || field.PkgPath != "" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			meta := schemaFields[t.Name()+"."+name]
+			property := typeSchema(field.Type, definitions)
+			if meta.ref != "" {
+				property = map[string]interface{}{"$ref": "#/definitions/" + meta.ref}
+			}
+			if field.Type.Kind() == reflect.String && meta.enum == nil {
+				property = map[string]interface{}{"anyOf": []interface{}{
+					property,
+					map[string]interface{}{"$ref": "#/definitions/SecretFile"},
+				}}
+			}
+			if meta.description != "" {
+				property["description"] = meta.description
+			}
+			if meta.enum != nil {
+				property["enum"] = meta.enum
+			}
+			if meta.def != nil {
+				property["default"] = meta.def
+			}
+			if meta.required {
+				required = append(required, name)
+			}
+			properties[name] = property
+		}
+	}
+	collect(t)
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if required != nil {
+		schema["required"] = required
+	}
+	return schema
+}
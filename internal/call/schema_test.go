@@ -0,0 +1,52 @@
+package call
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// schemaPath is the published schema, kept in sync with ConfigSchema
+const schemaPath = "../../examples/config.schema.json"
+
+func TestConfigSchemaIsUpToDate(t *testing.T) {
+	generated, err := ConfigSchema()
+	assert.Nil(t, err)
+	published, err := ioutil.ReadFile(schemaPath)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(generated), string(published), "run ConfigSchema and save its output to "+schemaPath)
+}
+
+func TestConfigSchemaDocumentsEveryField(t *testing.T) {
+	generated, _ := ConfigSchema()
+	var schema struct {
+		Definitions map[string]struct {
+			Properties map[string]map[string]interface{} `json:"properties"`
+		} `json:"definitions"`
+	}
+	assert.Nil(t, json.Unmarshal(generated, &schema))
+	for definition, object := range schema.Definitions {
+		for name, property := range object.Properties {
+			assert.NotEmpty(t, property["description"], "%s.%s has no description", definition, name)
+		}
+	}
+}
+
+func TestConfigSchemaDefaultsAndEnums(t *testing.T) {
+	generated, _ := ConfigSchema()
+	var schema map[string]interface{}
+	assert.Nil(t, json.Unmarshal(generated, &schema))
+	config := schema["definitions"].(map[string]interface{})["Config"].(map[string]interface{})
+	properties := config["properties"].(map[string]interface{})
+
+	assert.Equal(t, float64(10), properties["attempts"].(map[string]interface{})["default"])
+	assert.Equal(t, float64(10), properties["concurrent"].(map[string]interface{})["default"])
+	methods := properties["method"].(map[string]interface{})["enum"].([]interface{})
+	assert.Len(t, methods, len(allowedMethods))
+	for _, method := range methods {
+		assert.True(t, isMethodAllowed(method.(string)))
+	}
+	assert.Equal(t, false, config["additionalProperties"])
+}
@@ -0,0 +1,200 @@
+package call
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SecretMask replaces secret values in every output
+const SecretMask = "****"
+
+// secretFileKey marks an object standing for the content of a file,
+// such as a mounted k8s secret: {"secret_file": "/path/to/secret"}
+const secretFileKey = "secret_file"
+
+// minSecretLength avoids masking every occurrence of tiny values
+const minSecretLength = 4
+
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// secrets holds every value that must not be outputted
+var secrets = struct {
+	sync.RWMutex
+	values map[string]struct{}
+}{values: make(map[string]struct{})}
+
+// registerSecret marks a value to be masked by MaskSecrets
+func registerSecret(value string) {
+	if len(value) < minSecretLength {
+		return
+	}
+	secrets.Lock()
+	defer secrets.Unlock()
+	secrets.values[value] = struct{}{}
+}
+
+// MaskSecrets replaces every known secret in s by SecretMask. Secrets
+// are the values read from secret files, the auth credentials of the
+// calls built or configured and the variables interpolated in auth,
+// header, cookie and proxy fields.
+// Longer secrets go first, so one holding another is fully masked
+func MaskSecrets(s string) string {
+	secrets.RLock()
+	values := make([]string, 0, len(secrets.values))
+	for value := range secrets.values {
+		values = append(values, value)
+	}
+	secrets.RUnlock()
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, value := range values {
+		s = strings.ReplaceAll(s, value, SecretMask)
+	}
+	return s
+}
+
+// registerAuthSecrets marks the credentials of an auth block as secrets
+func registerAuthSecrets(auth *Auth) {
+	if auth == nil {
+		return
+	}
+	for _, value := range []string{auth.Password, auth.Token, auth.ClientSecret, auth.Secret, auth.SessionToken} {
+		registerSecret(value)
+	}
+}
+
+// sensitiveKeys name the config fields whose values interpolated from
+// variables are registered as secrets
+var sensitiveKeys = map[string]bool{"auth": true, "header": true, "cookie": true, "proxy": true}
+
+// expand walks a decoded config document, interpolating environment
+// variables in every string and replacing secret_file objects by the
+// content of their files. variables take precedence over the process
+// environment
+func expand(doc interface{}, variables map[string]string) (interface{}, error) {
+	return expandNode(doc, variables, false)
+}
+
+// expandNode expands a node of a config document, registering the
+// values interpolated in it as secrets when it is sensitive
+func expandNode(doc interface{}, variables map[string]string, sensitive bool) (interface{}, error) {
+	switch node := doc.(type) {
+	case string:
+		if sensitive {
+			registerEnvSecrets(node, variables)
+		}
+		return interpolateEnv(node, variables)
+	case []interface{}:
+		for i := range node {
+			value, err := expandNode(node[i], variables, sensitive)
+			if err != nil {
+				return nil, keyError(strconv.Itoa(i), err)
+			}
+			node[i] = value
+		}
+	case map[string]interface{}:
+		if path, ok := node[secretFileKey].(string); ok && len(node) == 1 {
+			return readSecretFile(path, variables)
+		}
+		for key := range node {
+			value, err := expandNode(node[key], variables, sensitive || sensitiveKeys[key])
+			if err != nil {
+				return nil, keyError(key, err)
+			}
+			node[key] = value
+		}
+	}
+	return doc, nil
+}
+
+// keyError nests an error expanding a node under the key holding it
+func keyError(key string, err error) error {
+	if e, ok := err.(fieldError); ok {
+		return fieldError{key + "." + e.field, e.err}
+	}
+	return fieldError{key, err}
+}
+
+// registerEnvSecrets marks the values of the variables s references as
+// secrets. Defaults are left out, as the config itself holds them
+func registerEnvSecrets(s string, variables map[string]string) {
+	for _, groups := range envPattern.FindAllStringSubmatch(s, -1) {
+		value, ok := variables[groups[1]]
+		if !ok {
+			value = os.Getenv(groups[1])
+		}
+		registerSecret(value)
+	}
+}
+
+// interpolateEnv replaces ${VAR} and ${VAR:-default} by the value of
+// the variable VAR, looked up in variables and then in the process
+// environment. Unset variables without default fail
+func interpolateEnv(s string, variables map[string]string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var undefined []string
+	s = envPattern.ReplaceAllStringFunc(s, func(match string) string {
+		groups := envPattern.FindStringSubmatch(match)
+		value, ok := variables[groups[1]]
+		if !ok {
+			value, ok = os.LookupEnv(groups[1])
+		}
+		if ok && (value != "" || groups[2] == "") {
+			return value
+		}
+		if groups[2] != "" {
+			return groups[3]
+		}
+		undefined = append(undefined, groups[1])
+		return match
+	})
+	if len(undefined) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrUndefinedVariable, strings.Join(undefined, ", "))
+	}
+	return s, nil
+}
+
+// readSecretFile reads a secret value, dropping the trailing newline
+// most tools add when writing files
+func readSecretFile(path string, variables map[string]string) (string, error) {
+	path, err := interpolateEnv(path, variables)
+	if err != nil {
+		return "", err
+	}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimRight(string(raw), "\r\n")
+	registerSecret(value)
+	return value, nil
+}
+
+// secretFiles returns the paths of the secret files of a decoded config
+// document. Paths with undefined variables are skipped
+func secretFiles(doc interface{}, variables map[string]string) (paths []string) {
+	switch node := doc.(type) {
+	case []interface{}:
+		for _, child := range node {
+			paths = append(paths, secretFiles(child, variables)...)
+		}
+	case map[string]interface{}:
+		if path, ok := node[secretFileKey].(string); ok && len(node) == 1 {
+			if path, err := interpolateEnv(path, variables); err == nil {
+				paths = append(paths, path)
+			}
+			return
+		}
+		for _, child := range node {
+			paths = append(paths, secretFiles(child, variables)...)
+		}
+	}
+	return
+}
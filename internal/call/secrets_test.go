@@ -0,0 +1,114 @@
+package call
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolateEnv(t *testing.T) {
+	os.Setenv("CALL_IT_HOST", "api.example.com")
+	os.Setenv("CALL_IT_EMPTY", "")
+	defer os.Unsetenv("CALL_IT_HOST")
+	defer os.Unsetenv("CALL_IT_EMPTY")
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{name: "no variables", in: "http://localhost", want: "http://localhost"},
+		{name: "set variable", in: "https://${CALL_IT_HOST}/v1", want: "https://api.example.com/v1"},
+		{name: "default of unset variable", in: "${CALL_IT_MISSING:-8080}", want: "8080"},
+		{name: "default of empty variable", in: "${CALL_IT_EMPTY:-none}", want: "none"},
+		{name: "empty default", in: "a${CALL_IT_MISSING:-}b", want: "ab"},
+		{name: "empty variable without default", in: "a${CALL_IT_EMPTY}b", want: "ab"},
+		{name: "unset variable", in: "${CALL_IT_MISSING}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := interpolateEnv(tt.in, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("interpolateEnv() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadConfigWithEnvAndSecretFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "call-it")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	secretPath := filepath.Join(dir, "api-key")
+	assert.Nil(t, ioutil.WriteFile(secretPath, []byte("k8s-s3cr3t\n"), 0600))
+	os.Setenv("CALL_IT_SECRETS", dir)
+	defer os.Unsetenv("CALL_IT_SECRETS")
+
+	stdin = strings.NewReader(`[{
+		"name": "secret",
+		"method": "GET",
+		"url": "${CALL_IT_URL:-http://localhost:9999}/items",
+		"header": {"X-Api-Key": [{"secret_file": "${CALL_IT_SECRETS}/api-key"}]}
+	}]`)
+	defer func() { stdin = os.Stdin }()
+	gotC, err := LoadConfig(StdinPath)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:9999/items", gotC[0].URL)
+	assert.Equal(t, "k8s-s3cr3t", gotC[0].Header["X-Api-Key"][0])
+	assert.Equal(t, "key=****", MaskSecrets("key=k8s-s3cr3t"))
+}
+
+func TestLoadConfigWithMissingSecretFile(t *testing.T) {
+	stdin = strings.NewReader(`[{"name": "secret", "auth": {"type": "bearer", "token": {"secret_file": "/nonexistent/token"}}}]`)
+	defer func() { stdin = os.Stdin }()
+	_, err := LoadConfig(StdinPath)
+	assert.NotNil(t, err)
+}
+
+func TestMaskSecretsOfAuth(t *testing.T) {
+	c := Config{Name: "auth", Method: "GET", URL: "http://www.foo.com", Auth: &Auth{Type: AuthBearer, Token: "bearer-t0ken"}}
+	assert.Nil(t, c.CheckDefaults())
+	assert.Equal(t, "Authorization: Bearer bearer-t0ken", MaskSecrets("Authorization: Bearer bearer-t0ken"))
+
+	var call ConcurrentCall
+	call.SetConfig(c)
+	assert.Equal(t, "Authorization: Bearer ****", MaskSecrets("Authorization: Bearer bearer-t0ken"))
+	assert.Equal(t, "abc", MaskSecrets("abc"))
+}
+
+func TestValidateConfigFileRegistersNoSecret(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `[{"name": "auth", "method": "GET", "url": "http://www.foo.com", "auth": {"type": "bearer", "token": "validated-t0ken"}}]`)
+	problems, err := ValidateConfigFile(path, LoadOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, problems)
+	assert.Equal(t, "validated-t0ken", MaskSecrets("validated-t0ken"))
+}
+
+func TestLoadConfigMasksInterpolatedCredentials(t *testing.T) {
+	os.Setenv("CALL_IT_API_KEY", "env-s3cr3t")
+	os.Setenv("CALL_IT_HOST", "www.interpolated.com")
+	defer os.Unsetenv("CALL_IT_API_KEY")
+	defer os.Unsetenv("CALL_IT_HOST")
+
+	stdin = strings.NewReader(`[{
+		"name": "interpolated",
+		"method": "GET",
+		"url": "http://${CALL_IT_HOST}/items",
+		"header": {"Authorization": ["Bearer ${CALL_IT_API_KEY}"]}
+	}]`)
+	defer func() { stdin = os.Stdin }()
+	gotC, err := LoadConfig(StdinPath)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer env-s3cr3t", gotC[0].Header["Authorization"][0])
+	assert.Equal(t, "Authorization: Bearer ****", MaskSecrets("Authorization: Bearer env-s3cr3t"))
+	assert.Equal(t, "http://www.interpolated.com/items", MaskSecrets(gotC[0].URL))
+}
@@ -0,0 +1,307 @@
+package call
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A ServeRoute tells the mock server how to answer the requests of a
+// path. Failures, such as error statuses and dropped connections, are
+// drawn for each request
+type ServeRoute struct {
+	Method      string        `json:"method,omitempty"`       // all methods when empty
+	Path        string        `json:"path"`                   // exact path, or a prefix ending with *
+	Latency     *ThinkTime    `json:"latency,omitempty"`      // wait before answering, in seconds
+	Statuses    []ServeStatus `json:"statuses,omitempty"`     // weighted status codes, 200 when empty
+	Body        string        `json:"body,omitempty"`         // response body, filler text of Size bytes when empty
+	Size        int           `json:"size,omitempty"`         // bytes of filler text
+	ContentType string        `json:"content_type,omitempty"` // text/plain when empty
+	Stream      float64       `json:"stream,omitempty"`       // seconds the body is slowly sent over, in chunks
+	Drop        float64       `json:"drop,omitempty"`         // ratio of connections closed without a response
+	RateLimit   int           `json:"rate_limit,omitempty"`   // requests per second answered, the others get a 429
+}
+
+// A ServeStatus is a status code a route answers, Weight times out of
+// the sum of the weights of the route
+type ServeStatus struct {
+	Code   int `json:"code"`
+	Weight int `json:"weight,omitempty"`
+}
+
+// streamChunks is the number of chunks streamed bodies are sent in
+const streamChunks = 10
+
+// serveFiller is repeated to fill bodies of a given size
+const serveFiller = "call-it mock server "
+
+// DefaultServeRoutes are the routes of the mock server when no file
+// configures them, one per behaviour
+var DefaultServeRoutes = []ServeRoute{
+	{Path: "/", Body: `{"message": "call-it mock server"}`, ContentType: "application/json"},
+	{Path: "/slow", Latency: &ThinkTime{Distribution: DistributionNormal, Mean: 0.5, StdDev: 0.1}},
+	{Path: "/flaky", Statuses: []ServeStatus{{Code: http.StatusOK, Weight: 9}, {Code: http.StatusInternalServerError, Weight: 1}}},
+	{Path: "/large", Size: 1 << 20},
+	{Path: "/stream", Size: 64 << 10, Stream: 2},
+	{Path: "/drop", Drop: 0.2},
+	{Path: "/limited", RateLimit: 10},
+}
+
+// A MockServer answers requests the way its routes tell, to be used as
+// a local target
+type MockServer struct {
+	routes   []ServeRoute
+	limiters []*rateLimiter
+}
+
+// rateLimiter counts the requests of a route in the current second
+type rateLimiter struct {
+	mu     sync.Mutex
+	window time.Time
+	count  int
+}
+
+// allow tells whether a request fits in the limit of the current second
+func (l *rateLimiter) allow(limit int, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if second := now.Truncate(time.Second); !second.Equal(l.window) {
+		l.window, l.count = second, 0
+	}
+	l.count++
+	return l.count <= limit
+}
+
+// NewMockServer creates a MockServer with routes, checked and filled
+// with their defaults. Routes are matched in order
+func NewMockServer(routes []ServeRoute) (*MockServer, error) {
+	server := &MockServer{routes: append([]ServeRoute(nil), routes...)}
+	for i := range server.routes {
+		if err := server.routes[i].check(); err != nil {
+			return nil, fieldError{"routes." + strconv.Itoa(i) + "." + err.field, err.err}
+		}
+		server.limiters = append(server.limiters, &rateLimiter{})
+	}
+	return server, nil
+}
+
+// check validates a route and fills its default values
+func (r *ServeRoute) check() *fieldError {
+	r.Method = strings.ToUpper(r.Method)
+	r.Statuses = append([]ServeStatus(nil), r.Statuses...)
+	switch {
+	case !strings.HasPrefix(r.Path, "/"):
+		return &fieldError{"path", fmt.Errorf("%w: path must start with /", ErrInvalidRoute)}
+	case r.Method != "" && !isMethodAllowed(r.Method):
+		return &fieldError{"method", ErrMethodNotAllowed}
+	case r.Size < 0:
+		return &fieldError{"size", fmt.Errorf("%w: negative size", ErrInvalidRoute)}
+	case r.Stream < 0:
+		return &fieldError{"stream", fmt.Errorf("%w: negative stream duration", ErrInvalidRoute)}
+	case r.Drop < 0 || r.Drop > 1:
+		return &fieldError{"drop", fmt.Errorf("%w: drop must be between 0 and 1", ErrInvalidRoute)}
+	case r.RateLimit < 0:
+		return &fieldError{"rate_limit", fmt.Errorf("%w: negative rate limit", ErrInvalidRoute)}
+	}
+	if r.Latency != nil {
+		if err := r.Latency.check(); err != nil {
+			return &fieldError{"latency", err}
+		}
+	}
+	for i, status := range r.Statuses {
+		field := "statuses." + strconv.Itoa(i)
+		if status.Code < 100 || status.Code > 599 {
+			return &fieldError{field + ".code", fmt.Errorf("%w: bad status code %d", ErrInvalidRoute, status.Code)}
+		}
+		if status.Weight < 0 {
+			return &fieldError{field + ".weight", ErrInvalidWeight}
+		}
+		if status.Weight == 0 {
+			r.Statuses[i].Weight = 1
+		}
+	}
+	if r.ContentType == "" {
+		r.ContentType = "text/plain; charset=utf-8"
+	}
+	return nil
+}
+
+// matches tells whether a route answers a request
+func (r *ServeRoute) matches(req *http.Request) bool {
+	if r.Method != "" && r.Method != req.Method {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(r.Path, "*"); ok {
+		return strings.HasPrefix(req.URL.Path, prefix)
+	}
+	return req.URL.Path == r.Path
+}
+
+// status draws the status code of a response
+func (r *ServeRoute) status() int {
+	if len(r.Statuses) == 0 {
+		return http.StatusOK
+	}
+	cumulative := make([]int, len(r.Statuses))
+	total := 0
+	for i, status := range r.Statuses {
+		total += status.Weight
+		cumulative[i] = total
+	}
+	return r.Statuses[pickEndpoint(cumulative, rand.Intn(total))].Code
+}
+
+// body returns the response body of a route
+func (r *ServeRoute) body() []byte {
+	if r.Body != "" {
+		return []byte(r.Body)
+	}
+	return []byte(strings.Repeat(serveFiller, r.Size/len(serveFiller)+1)[:r.Size])
+}
+
+// ServeHTTP answers a request with the first route matching it, or a
+// 404 when none does
+func (s *MockServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	for i := range s.routes {
+		if s.routes[i].matches(req) {
+			s.answer(w, req, &s.routes[i], s.limiters[i])
+			return
+		}
+	}
+	http.NotFound(w, req)
+}
+
+// answer answers a request the way route tells: rate limited requests
+// get a 429 right away, the others wait for the latency and then have
+// their connection dropped or get a response
+func (s *MockServer) answer(w http.ResponseWriter, req *http.Request, route *ServeRoute, limiter *rateLimiter) {
+	if route.RateLimit > 0 && !limiter.allow(route.RateLimit, time.Now()) {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+	if route.Latency != nil && !sleep(req.Context(), route.Latency.duration()) {
+		return
+	}
+	if route.Drop > 0 && rand.Float64() < route.Drop {
+		drop(w)
+		return
+	}
+
+	body := route.body()
+	w.Header().Set("Content-Type", route.ContentType)
+	if route.Stream <= 0 || len(body) == 0 {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(route.status())
+		w.Write(body)
+		return
+	}
+	w.WriteHeader(route.status())
+	flusher, _ := w.(http.Flusher)
+	chunk := (len(body) + streamChunks - 1) / streamChunks
+	pause := time.Duration(route.Stream * float64(time.Second) / streamChunks)
+	for start := 0; start < len(body); start += chunk {
+		if start > 0 && !sleep(req.Context(), pause) {
+			return
+		}
+		w.Write(body[start:min(start+chunk, len(body))])
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// sleep waits for d, returning false when the request is canceled first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// drop closes the connection of a request without answering it. When
+// the connection cannot be taken over, as with HTTP/2, the handler
+// aborts instead, which resets the stream
+func drop(w http.ResponseWriter) {
+	if conn, _, err := http.NewResponseController(w).Hijack(); err == nil {
+		conn.Close()
+		return
+	}
+	panic(http.ErrAbortHandler)
+}
+
+// LoadServeRoutes reads the routes of the mock server from a JSON, YAML
+// or TOML file, or the standard input for StdinPath, holding them in a
+// routes list. Environment variables are expanded as in config files
+func LoadServeRoutes(path string) ([]ServeRoute, error) {
+	raw, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	format, err := detectFormat(path, raw)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeDocument(raw, format)
+	if err != nil {
+		return nil, err
+	}
+	if doc, err = expand(doc, nil); err != nil {
+		return nil, err
+	}
+	converted, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Routes []ServeRoute `json:"routes"`
+	}
+	if err := json.Unmarshal(converted, &file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRoute, err)
+	}
+	if len(file.Routes) == 0 {
+		return nil, fmt.Errorf("%w: no routes", ErrInvalidRoute)
+	}
+	return file.Routes, nil
+}
+
+// Serve runs a MockServer with routes listening on the listen address,
+// such as :9999, until stop is closed
+func Serve(listen string, routes []ServeRoute, stop <-chan struct{}) error {
+	handler, err := NewMockServer(routes)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: handler}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-stop:
+		// streamed and slow responses are not waited for
+		if err := server.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			return err
+		}
+		return nil
+	}
+}
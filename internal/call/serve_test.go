@@ -0,0 +1,213 @@
+package call
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newMockServer returns a test server running a MockServer with routes
+func newMockServer(t *testing.T, routes []ServeRoute) *httptest.Server {
+	handler, err := NewMockServer(routes)
+	assert.Nil(t, err)
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// mockRequest requests path, returning the status and body of the response
+func mockRequest(t *testing.T, server *httptest.Server, method, path string) (int, string) {
+	req, _ := http.NewRequest(method, server.URL+path, nil)
+	response, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	return response.StatusCode, string(body)
+}
+
+func TestMockServerRoutes(t *testing.T) {
+	server := newMockServer(t, []ServeRoute{
+		{Method: "post", Path: "/users", Statuses: []ServeStatus{{Code: http.StatusCreated}}, Body: "created"},
+		{Path: "/users", Body: "listed"},
+		{Path: "/files/*", Size: 45},
+	})
+
+	status, body := mockRequest(t, server, http.MethodPost, "/users")
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "created", body)
+
+	status, body = mockRequest(t, server, http.MethodGet, "/users")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "listed", body)
+
+	status, body = mockRequest(t, server, http.MethodGet, "/files/a/b.txt")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, body, 45)
+	assert.Equal(t, "call-it mock server call-it mock server call-", body)
+
+	status, _ = mockRequest(t, server, http.MethodGet, "/users/1")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestMockServerStatuses(t *testing.T) {
+	server := newMockServer(t, []ServeRoute{{Path: "/", Statuses: []ServeStatus{
+		{Code: http.StatusOK, Weight: 1},
+		{Code: http.StatusServiceUnavailable, Weight: 1},
+		{Code: http.StatusTeapot, Weight: 0}, // defaults to 1
+	}}})
+
+	seen := make(map[int]int)
+	for i := 0; i < 300; i++ {
+		status, _ := mockRequest(t, server, http.MethodGet, "/")
+		seen[status]++
+	}
+	assert.Len(t, seen, 3)
+	for status, count := range seen {
+		assert.True(t, count > 50, "status %d: %d", status, count)
+	}
+}
+
+func TestMockServerLatency(t *testing.T) {
+	server := newMockServer(t, []ServeRoute{{Path: "/", Latency: &ThinkTime{Distribution: DistributionFixed, Value: 0.1}}})
+
+	beginning := time.Now()
+	status, _ := mockRequest(t, server, http.MethodGet, "/")
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, time.Since(beginning) >= 100*time.Millisecond)
+}
+
+func TestMockServerStream(t *testing.T) {
+	server := newMockServer(t, []ServeRoute{{Path: "/", Size: 1000, Stream: 0.2}})
+
+	beginning := time.Now()
+	response, err := http.Get(server.URL)
+	assert.Nil(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, int64(-1), response.ContentLength)
+	first := make([]byte, 1000)
+	n, _ := response.Body.Read(first)
+	assert.Equal(t, 100, n)
+	assert.True(t, time.Since(beginning) < 100*time.Millisecond)
+
+	rest, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.Len(t, rest, 900)
+	assert.True(t, time.Since(beginning) >= 180*time.Millisecond)
+}
+
+func TestMockServerDrop(t *testing.T) {
+	server := newMockServer(t, []ServeRoute{{Path: "/", Drop: 1}})
+
+	_, err := http.Get(server.URL)
+	assert.NotNil(t, err)
+}
+
+func TestMockServerRateLimit(t *testing.T) {
+	server := newMockServer(t, []ServeRoute{{Path: "/", RateLimit: 2}})
+
+	// requests straddling a second boundary could all pass
+	for time.Now().Nanosecond() > 800*int(time.Millisecond) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	statuses := make([]int, 3)
+	for i := range statuses {
+		statuses[i], _ = mockRequest(t, server, http.MethodGet, "/")
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, statuses)
+}
+
+func TestNewMockServerInvalid(t *testing.T) {
+	tests := []struct {
+		route ServeRoute
+		field string
+		err   error
+	}{
+		{ServeRoute{Path: "users"}, "routes.0.path", ErrInvalidRoute},
+		{ServeRoute{Path: "/", Method: "BREW"}, "routes.0.method", ErrMethodNotAllowed},
+		{ServeRoute{Path: "/", Size: -1}, "routes.0.size", ErrInvalidRoute},
+		{ServeRoute{Path: "/", Stream: -1}, "routes.0.stream", ErrInvalidRoute},
+		{ServeRoute{Path: "/", Drop: 1.5}, "routes.0.drop", ErrInvalidRoute},
+		{ServeRoute{Path: "/", RateLimit: -1}, "routes.0.rate_limit", ErrInvalidRoute},
+		{ServeRoute{Path: "/", Latency: &ThinkTime{Distribution: "pareto"}}, "routes.0.latency", ErrInvalidThinkTime},
+		{ServeRoute{Path: "/", Statuses: []ServeStatus{{Code: 99}}}, "routes.0.statuses.0.code", ErrInvalidRoute},
+		{ServeRoute{Path: "/", Statuses: []ServeStatus{{Code: 200, Weight: -1}}}, "routes.0.statuses.0.weight", ErrInvalidWeight},
+	}
+	for _, test := range tests {
+		_, err := NewMockServer([]ServeRoute{test.route})
+		assert.True(t, errors.Is(err, test.err), "%s: %v", test.field, err)
+		assert.Contains(t, err.Error(), test.field+": ")
+	}
+}
+
+func TestLoadServeRoutes(t *testing.T) {
+	t.Setenv("MOCK_BODY", "hello")
+	path := writeConfigFile(t, "routes.yaml", `
+routes:
+  - path: /hello
+    method: get
+    body: ${MOCK_BODY}
+    latency:
+      distribution: uniform
+      min: 0.01
+      max: 0.02
+    statuses:
+      - code: 200
+        weight: 9
+      - code: 500
+  - path: /limited
+    rate_limit: 5
+`)
+	routes, err := LoadServeRoutes(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []ServeRoute{
+		{
+			Method:   "get",
+			Path:     "/hello",
+			Body:     "hello",
+			Latency:  &ThinkTime{Distribution: DistributionUniform, Min: 0.01, Max: 0.02},
+			Statuses: []ServeStatus{{Code: 200, Weight: 9}, {Code: 500}},
+		},
+		{Path: "/limited", RateLimit: 5},
+	}, routes)
+
+	_, err = LoadServeRoutes(writeConfigFile(t, "empty.json", `{"routes": []}`))
+	assert.True(t, errors.Is(err, ErrInvalidRoute))
+	_, err = LoadServeRoutes(writeConfigFile(t, "bad.json", `{"routes": [{"size": "large"}]}`))
+	assert.True(t, errors.Is(err, ErrInvalidRoute))
+}
+
+func TestServe(t *testing.T) {
+	assert.NotNil(t, Serve("127.0.0.1:0", []ServeRoute{{Path: "users"}}, nil))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	listen := listener.Addr().String()
+	listener.Close()
+
+	stop := make(chan struct{})
+	served := make(chan error)
+	go func() {
+		served <- Serve(listen, DefaultServeRoutes, stop)
+	}()
+	var response *http.Response
+	for i := 0; i < 50; i++ {
+		if response, err = http.Get("http://" + listen + "/"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	assert.Equal(t, `{"message": "call-it mock server"}`, string(body))
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+
+	close(stop)
+	assert.Nil(t, <-served)
+}
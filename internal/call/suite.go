@@ -0,0 +1,170 @@
+package call
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// A Suite runs the calls of a config file. By default they run one
+// after another; parallel suites run them all at once or, when entries
+// have a group, run the groups one after another in the order they
+// first appear, all the calls of a group at once
+type Suite struct {
+	Parallel       bool `json:"parallel,omitempty"`        // run the calls at the same time
+	MaxConcurrency int  `json:"max_concurrency,omitempty"` // requests in flight across all calls, unlimited when 0
+
+	History *History `json:"-"` // keeps the result of every call, none when nil
+
+	calls      []ConcurrentCall
+	historyErr error // results of the last run the history could not keep
+}
+
+// limitTransport holds one of a limited number of slots from the start
+// of each request until its response body is closed
+type limitTransport struct {
+	base  http.RoundTripper // nil means http.DefaultTransport
+	slots chan struct{}
+}
+
+// limitedBody releases the slot of a request once closed
+type limitedBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// decodeSuite reads the suite settings of a decoded config document
+func decodeSuite(doc interface{}) (suite Suite, err error) {
+	object, ok := doc.(map[string]interface{})
+	if !ok || object["suite"] == nil {
+		return
+	}
+	converted, err := json.Marshal(object["suite"])
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(converted, &suite); err != nil {
+		return suite, fmt.Errorf("%w: suite: %v", ErrInvalidDocument, err)
+	}
+	if suite.MaxConcurrency < 0 {
+		return suite, fmt.Errorf("%w: suite max_concurrency cannot be negative", ErrInvalidDocument)
+	}
+	return
+}
+
+// BuildSuite parses a Config file like BuildCallsWithOptions, along
+// with its suite settings
+func BuildSuite(path string, options LoadOptions) (suite Suite, err error) {
+	configs, suite, err := loadConfigFile(path, options)
+	if err != nil {
+		return
+	}
+	suite.calls, err = buildCalls(configs)
+	return
+}
+
+// GetCalls returns the calls of the suite
+func (s *Suite) GetCalls() []ConcurrentCall {
+	return s.calls
+}
+
+// GetHistoryErr returns why the History could not keep results of the
+// last run, nil when it kept them all
+func (s *Suite) GetHistoryErr() error {
+	return s.historyErr
+}
+
+// Run makes every call of the suite and returns their results, in the
+// order of the calls whatever the order they finished in. Each result
+// is then saved to the History, if any
+func (s *Suite) Run() []Result {
+	results := s.run()
+	s.historyErr = nil
+	if s.History != nil {
+		var errs []error
+		for i, result := range results {
+			if _, err := s.History.Save(s.calls[i].config, result); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		s.historyErr = errors.Join(errs...)
+	}
+	return results
+}
+
+// run makes every call of the suite
+func (s *Suite) run() []Result {
+	results := make([]Result, len(s.calls))
+	var limiter chan struct{}
+	if s.MaxConcurrency > 0 {
+		limiter = make(chan struct{}, s.MaxConcurrency)
+	}
+	if !s.Parallel {
+		for i := range s.calls {
+			call := s.calls[i]
+			call.limiter = limiter
+			results[i] = call.MakeIt()
+		}
+		return results
+	}
+	for _, group := range s.groups() {
+		var wg sync.WaitGroup
+		wg.Add(len(group))
+		for _, i := range group {
+			call := s.calls[i]
+			call.limiter, call.quiet = limiter, true
+			go func(i int) {
+				defer wg.Done()
+				results[i] = call.MakeIt()
+			}(i)
+		}
+		wg.Wait()
+	}
+	return results
+}
+
+// groups returns the indexes of the calls of each group, in the order
+// the groups first appear
+func (s *Suite) groups() (groups [][]int) {
+	positions := make(map[string]int)
+	for i, call := range s.calls {
+		position, ok := positions[call.config.Group]
+		if !ok {
+			position = len(groups)
+			positions[call.config.Group] = position
+			groups = append(groups, nil)
+		}
+		groups[position] = append(groups[position], i)
+	}
+	return
+}
+
+func (t *limitTransport) transport() http.RoundTripper {
+	if t.base != nil {
+		return t.base
+	}
+	return http.DefaultTransport
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.slots <- struct{}{}
+	var once sync.Once
+	release := func() { once.Do(func() { <-t.slots }) }
+	resp, err := t.transport().RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// Close implements io.Closer
+func (b *limitedBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,156 @@
+package call
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// inFlightServer counts the requests it is serving at the same time,
+// keeping the maximum, and the order in which paths were first hit
+type inFlightServer struct {
+	*httptest.Server
+	current, max int32
+
+	mu    sync.Mutex
+	seen  map[string]bool
+	paths []string
+}
+
+func newInFlightServer() *inFlightServer {
+	s := &inFlightServer{seen: make(map[string]bool)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&s.current, 1)
+		defer atomic.AddInt32(&s.current, -1)
+		for {
+			max := atomic.LoadInt32(&s.max)
+			if n <= max || atomic.CompareAndSwapInt32(&s.max, max, n) {
+				break
+			}
+		}
+		s.mu.Lock()
+		if !s.seen[r.URL.Path] {
+			s.seen[r.URL.Path] = true
+			s.paths = append(s.paths, r.URL.Path)
+		}
+		s.mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+	}))
+	return s
+}
+
+// suiteCall builds a call of attempts requests, all at once, to a path
+func suiteCall(server *inFlightServer, name, group string, attempts int) ConcurrentCall {
+	config := Config{Name: name, Group: group, Method: http.MethodGet, URL: server.URL + "/" + name}
+	call := ConcurrentCall{Attempts: attempts, ConcurrentAttempts: attempts, config: config}
+	call.URL, _ = url.Parse(config.URL)
+	return call
+}
+
+func TestSuiteRunsCallsInParallel(t *testing.T) {
+	server := newInFlightServer()
+	defer server.Close()
+	suite := Suite{Parallel: true, calls: []ConcurrentCall{
+		suiteCall(server, "a", "", 4),
+		suiteCall(server, "b", "", 4),
+		suiteCall(server, "c", "", 4),
+	}}
+	results := suite.Run()
+
+	assert.True(t, atomic.LoadInt32(&server.max) > 4, "calls should overlap")
+	for i, name := range []string{"a", "b", "c"} {
+		assert.Equal(t, name, results[i].GetName())
+		assert.Equal(t, 4, results[i].status[200].total)
+	}
+	assert.Equal(t, 4, suite.GetCalls()[0].Attempts)
+}
+
+func TestSuiteRunsCallsOneByOne(t *testing.T) {
+	server := newInFlightServer()
+	defer server.Close()
+	suite := Suite{calls: []ConcurrentCall{suiteCall(server, "a", "", 4), suiteCall(server, "b", "", 4)}}
+	suite.Run()
+
+	assert.Equal(t, int32(4), atomic.LoadInt32(&server.max))
+	assert.Equal(t, []string{"/a", "/b"}, server.paths)
+}
+
+func TestSuiteCapsConcurrencyAcrossCalls(t *testing.T) {
+	server := newInFlightServer()
+	defer server.Close()
+	suite := Suite{Parallel: true, MaxConcurrency: 3, calls: []ConcurrentCall{
+		suiteCall(server, "a", "", 5),
+		suiteCall(server, "b", "", 5),
+	}}
+	results := suite.Run()
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&server.max))
+	assert.Equal(t, 5, results[0].status[200].total)
+	assert.Equal(t, 5, results[1].status[200].total)
+}
+
+func TestSuiteRunsGroupsOneAfterAnother(t *testing.T) {
+	server := newInFlightServer()
+	defer server.Close()
+	suite := Suite{Parallel: true, calls: []ConcurrentCall{
+		suiteCall(server, "setup", "first", 2),
+		suiteCall(server, "load-a", "second", 2),
+		suiteCall(server, "seed", "first", 2),
+		suiteCall(server, "load-b", "second", 2),
+	}}
+	assert.Equal(t, [][]int{{0, 2}, {1, 3}}, suite.groups())
+	suite.Run()
+
+	assert.Equal(t, int32(4), atomic.LoadInt32(&server.max))
+	assert.ElementsMatch(t, []string{"/setup", "/seed"}, server.paths[:2])
+	assert.ElementsMatch(t, []string{"/load-a", "/load-b"}, server.paths[2:])
+}
+
+func TestSuiteSavesResultsToHistory(t *testing.T) {
+	server := newInFlightServer()
+	defer server.Close()
+	history, err := OpenHistory(t.TempDir())
+	assert.Nil(t, err)
+	suite := Suite{History: history, calls: []ConcurrentCall{suiteCall(server, "a", "", 1), suiteCall(server, "b", "", 1)}}
+	suite.Run()
+
+	assert.Nil(t, suite.GetHistoryErr())
+	runs, _, err := history.List("")
+	assert.Nil(t, err)
+	assert.Len(t, runs, 2)
+	runs, _, _ = history.List("b")
+	assert.Len(t, runs, 1)
+
+	history.dir = filepath.Join(t.TempDir(), "missing")
+	suite.Run()
+	assert.NotNil(t, suite.GetHistoryErr())
+}
+
+func TestBuildSuite(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{
+  "suite": {"parallel": true, "max_concurrency": 20},
+  "cases": [
+    {"name": "a", "method": "GET", "url": "http://www.foo.com/a", "group": "reads"},
+    {"name": "b", "method": "GET", "url": "http://www.foo.com/b", "tags": ["slow"]}
+  ]
+}`)
+	suite, err := BuildSuite(path, LoadOptions{Exclude: []string{"slow"}})
+	assert.Nil(t, err)
+	assert.True(t, suite.Parallel)
+	assert.Equal(t, 20, suite.MaxConcurrency)
+	assert.Len(t, suite.GetCalls(), 1)
+	assert.Equal(t, "reads", suite.GetCalls()[0].config.Group)
+
+	for _, content := range []string{`{"suite": {"max_concurrency": -1}, "cases": []}`, `{"suite": {"parallel": "yes"}, "cases": []}`} {
+		_, err = BuildSuite(writeConfigFile(t, "config.json", content), LoadOptions{})
+		assert.NotNil(t, err, fmt.Sprint(content))
+	}
+}
@@ -0,0 +1,100 @@
+package call
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Supported ThinkTime distributions
+const (
+	DistributionFixed       = "fixed"
+	DistributionUniform     = "uniform"
+	DistributionNormal      = "normal"
+	DistributionExponential = "exponential"
+)
+
+// ThinkTime is how long, in seconds, a worker waits after each request
+// before the next one, the way a real client would. In config files it
+// is either a number, for a fixed wait, or a distribution object
+type ThinkTime struct {
+	Distribution string  `json:"distribution"`
+	Value        float64 `json:"value,omitempty"`  // fixed
+	Min          float64 `json:"min,omitempty"`    // uniform
+	Max          float64 `json:"max,omitempty"`    // uniform
+	Mean         float64 `json:"mean,omitempty"`   // normal and exponential
+	StdDev       float64 `json:"stddev,omitempty"` // normal
+}
+
+// UnmarshalJSON accepts both a fixed number of seconds and a distribution object
+func (t *ThinkTime) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*t = ThinkTime{Distribution: DistributionFixed, Value: seconds}
+		return nil
+	}
+	type thinkTime ThinkTime
+	*t = ThinkTime{}
+	return json.Unmarshal(data, (*thinkTime)(t))
+}
+
+// check validates the parameters of the distribution
+func (t *ThinkTime) check() error {
+	var invalid bool
+	switch t.Distribution {
+	case DistributionFixed:
+		invalid = t.Value < 0
+	case DistributionUniform:
+		invalid = t.Min < 0 || t.Max < t.Min
+	case DistributionNormal:
+		invalid = t.Mean < 0 || t.StdDev < 0
+	case DistributionExponential:
+		invalid = t.Mean <= 0
+	default:
+		return fmt.Errorf("%w: unknown distribution %q", ErrInvalidThinkTime, t.Distribution)
+	}
+	if invalid {
+		return fmt.Errorf("%w: bad %s parameters", ErrInvalidThinkTime, t.Distribution)
+	}
+	return nil
+}
+
+// duration samples the distribution. Negative samples of a normal
+// distribution are clamped to zero
+func (t *ThinkTime) duration() time.Duration {
+	var seconds float64
+	switch t.Distribution {
+	case DistributionFixed:
+		seconds = t.Value
+	case DistributionUniform:
+		seconds = t.Min + rand.Float64()*(t.Max-t.Min)
+	case DistributionNormal:
+		seconds = t.Mean + rand.NormFloat64()*t.StdDev
+	case DistributionExponential:
+		seconds = rand.ExpFloat64() * t.Mean
+	}
+	if seconds < 0 {
+		seconds = 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// think waits for a think time sample. A nil ThinkTime does not wait
+func think(t *ThinkTime) {
+	if t != nil {
+		time.Sleep(t.duration())
+	}
+}
+
+// pace waits until pacing seconds went by since beginning, so each
+// worker starts one iteration every pacing seconds at most
+func pace(beginning time.Time, pacing float64) {
+	if pacing <= 0 {
+		return
+	}
+	interval := time.Duration(pacing * float64(time.Second))
+	if elapsed := time.Since(beginning); elapsed < interval {
+		time.Sleep(interval - elapsed)
+	}
+}
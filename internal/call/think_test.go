@@ -0,0 +1,94 @@
+package call
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThinkTimeUnmarshalJSON(test *testing.T) {
+	var config Config
+	assert.Nil(test, json.Unmarshal([]byte(`{"think_time": 0.5}`), &config))
+	assert.Equal(test, &ThinkTime{Distribution: DistributionFixed, Value: 0.5}, config.ThinkTime)
+
+	assert.Nil(test, json.Unmarshal([]byte(`{"think_time": {"distribution": "uniform", "min": 1, "max": 2}}`), &config))
+	assert.Equal(test, &ThinkTime{Distribution: DistributionUniform, Min: 1, Max: 2}, config.ThinkTime)
+
+	assert.NotNil(test, json.Unmarshal([]byte(`{"think_time": "1s"}`), &config))
+}
+
+func TestThinkTimeDuration(test *testing.T) {
+	fixed := ThinkTime{Distribution: DistributionFixed, Value: 0.25}
+	assert.Equal(test, 250*time.Millisecond, fixed.duration())
+
+	uniform := ThinkTime{Distribution: DistributionUniform, Min: 1, Max: 2}
+	normal := ThinkTime{Distribution: DistributionNormal, Mean: 0.1, StdDev: 1}
+	exponential := ThinkTime{Distribution: DistributionExponential, Mean: 1}
+	for i := 0; i < 100; i++ {
+		d := uniform.duration()
+		assert.True(test, d >= time.Second && d <= 2*time.Second)
+		assert.True(test, normal.duration() >= 0)
+		assert.True(test, exponential.duration() >= 0)
+	}
+}
+
+func TestThinkTimeCheck(test *testing.T) {
+	assert.NotNil(test, (&ThinkTime{Distribution: "poisson"}).check())
+	assert.NotNil(test, (&ThinkTime{Distribution: DistributionUniform, Min: 2, Max: 1}).check())
+	assert.NotNil(test, (&ThinkTime{Distribution: DistributionExponential}).check())
+	assert.Nil(test, (&ThinkTime{Distribution: DistributionNormal, Mean: 1, StdDev: 0.1}).check())
+}
+
+func TestPace(test *testing.T) {
+	beginning := time.Now()
+	pace(beginning, 0.05)
+	assert.True(test, time.Since(beginning) >= 50*time.Millisecond)
+
+	beginning = time.Now().Add(-time.Second)
+	before := time.Now()
+	pace(beginning, 0.05)
+	assert.True(test, time.Since(before) < 50*time.Millisecond)
+}
+
+func TestThinkTimeIsExcludedFromLatency(test *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerScenarioResponders()
+
+	config := scenarioConfig()
+	config.ThinkTime = &ThinkTime{Distribution: DistributionFixed, Value: 0.05}
+	config.Pacing = 0.15
+	assert.Nil(test, config.CheckDefaults())
+	call := ConcurrentCall{Attempts: 4, ConcurrentAttempts: 2}
+	call.URL, _ = url.Parse(config.Steps[0].URL)
+	call.SetConfig(config)
+	beginning := time.Now()
+	result := call.MakeIt()
+
+	assert.Equal(test, 4, result.status[http.StatusOK].total)
+	assert.True(test, result.maxExecution < 0.05)
+	assert.True(test, time.Since(beginning) >= 300*time.Millisecond)
+}
+
+func TestThinkTimeIsExcludedFromAverage(test *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "http://www.foo.com", httpmock.NewStringResponder(200, ``))
+
+	config := Config{Name: "think", URL: "http://www.foo.com", Method: http.MethodGet}
+	config.ThinkTime = &ThinkTime{Distribution: DistributionFixed, Value: 0.1}
+	assert.Nil(test, config.CheckDefaults())
+	call := ConcurrentCall{Attempts: 4, ConcurrentAttempts: 2}
+	call.URL, _ = url.Parse(config.URL)
+	call.SetConfig(config)
+	result := call.MakeIt()
+
+	assert.Equal(test, 4, result.status[http.StatusOK].total)
+	assert.True(test, result.GetAvgExecution() < 0.1)
+	assert.True(test, result.GetTotalExecution() >= 0.2)
+}
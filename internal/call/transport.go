@@ -0,0 +1,114 @@
+package call
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported Config.HTTPVersion values
+const (
+	HTTPVersion11 = "1.1" // HTTP/1.1 only
+	HTTPVersion2  = "2"   // HTTP/2 when the server supports it over TLS, the default
+)
+
+// proxySchemes are the proxy protocols net/http speaks
+var proxySchemes = map[string]bool{"http": true, "https": true, "socks5": true}
+
+// checkTransport validates the connection settings of a Config
+func (c *Config) checkTransport() (errs []fieldError) {
+	if c.Proxy != "" {
+		if _, err := parseProxy(c.Proxy); err != nil {
+			errs = append(errs, fieldError{"proxy", err})
+		}
+	}
+	for i, entry := range c.Resolve {
+		if _, _, err := parseResolve(entry); err != nil {
+			errs = append(errs, fieldError{fmt.Sprintf("resolve.%d", i), err})
+		}
+	}
+	switch c.HTTPVersion {
+	case "", HTTPVersion11, HTTPVersion2:
+	default:
+		errs = append(errs, fieldError{"http_version", fmt.Errorf("%w: %q", ErrInvalidHTTPVersion, c.HTTPVersion)})
+	}
+	return
+}
+
+// newTransport returns the transport of the connection settings of a
+// Config, nil when it has none so http.DefaultTransport is used
+func newTransport(config Config) http.RoundTripper {
+	if !config.Insecure && config.Proxy == "" && len(config.Resolve) == 0 && config.HTTPVersion == "" {
+		return nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	if proxy, err := parseProxy(config.Proxy); err == nil && proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if len(config.Resolve) > 0 {
+		addresses := make(map[string]string)
+		for _, entry := range config.Resolve {
+			if hostPort, address, err := parseResolve(entry); err == nil {
+				addresses[hostPort] = address
+			}
+		}
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if address, ok := addresses[addr]; ok {
+				addr = address
+			}
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(config.HTTPVersion != HTTPVersion11)
+	transport.Protocols = protocols
+	return transport
+}
+
+// parseProxy parses a proxy the way curl does: [scheme://][user:password@]host[:port],
+// http being the default scheme. An empty proxy is nil
+func parseProxy(proxy string) (*url.URL, error) {
+	if proxy == "" {
+		return nil, nil
+	}
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	parsed, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProxy, err)
+	}
+	if !proxySchemes[parsed.Scheme] || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidProxy, proxy)
+	}
+	return parsed, nil
+}
+
+// parseResolve parses a resolve entry, host:port:address as in curl
+// --resolve, into the host:port it replaces and the address:port to
+// dial. Only the first of several comma separated addresses is used
+func parseResolve(entry string) (hostPort, address string, err error) {
+	parts := strings.SplitN(strings.TrimPrefix(entry, "+"), ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidResolve, entry)
+	}
+	if port, err := strconv.Atoi(parts[1]); err != nil || port <= 0 || port > 65535 {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidResolve, entry)
+	}
+	address = strings.Trim(strings.SplitN(parts[2], ",", 2)[0], "[]")
+	if net.ParseIP(address) == nil {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidResolve, entry)
+	}
+	return net.JoinHostPort(parts[0], parts[1]), net.JoinHostPort(address, parts[1]), nil
+}
@@ -0,0 +1,70 @@
+package call
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTransport(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   []error
+	}{
+		{name: "none", config: Config{}},
+		{name: "valid", config: Config{Proxy: "socks5://proxy.local:1080", Resolve: []string{"foo.com:443:127.0.0.1", "+bar.com:80:[::1],10.0.0.1"}, HTTPVersion: HTTPVersion11}},
+		{name: "proxy without scheme", config: Config{Proxy: "proxy.local:3128"}},
+		{name: "invalid proxy", config: Config{Proxy: "ftp://proxy.local"}, want: []error{ErrInvalidProxy}},
+		{name: "invalid resolve", config: Config{Resolve: []string{"foo.com:443", "foo.com:http:127.0.0.1", "foo.com:443:host"}}, want: []error{ErrInvalidResolve, ErrInvalidResolve, ErrInvalidResolve}},
+		{name: "invalid http version", config: Config{HTTPVersion: "3"}, want: []error{ErrInvalidHTTPVersion}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.config.checkTransport()
+			assert.Len(t, errs, len(tt.want))
+			for i, err := range errs {
+				assert.True(t, errors.Is(err, tt.want[i]), err.Error())
+			}
+		})
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	assert.Nil(t, newTransport(Config{}))
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	// the certificate of the test server is not trusted
+	_, err := (&http.Client{Transport: newTransport(Config{HTTPVersion: HTTPVersion2})}).Get(server.URL)
+	assert.NotNil(t, err)
+
+	config := Config{Insecure: true, Resolve: []string{"call-it.test:" + serverURL.Port() + ":127.0.0.1"}, HTTPVersion: HTTPVersion11}
+	response, err := (&http.Client{Transport: newTransport(config)}).Get("https://call-it.test:" + serverURL.Port())
+	assert.Nil(t, err)
+	assert.Equal(t, "HTTP/1.1", response.Proto)
+	response.Body.Close()
+}
+
+func TestNewTransportProxy(t *testing.T) {
+	var requested string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.String() + " " + r.Header.Get("Proxy-Authorization")
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	client := newClients(Config{Proxy: "bob:pw@" + proxyURL.Host}, 1, nil)[0]
+	response, err := client.Get("http://www.foo.com/path")
+	assert.Nil(t, err)
+	response.Body.Close()
+	assert.Equal(t, "http://www.foo.com/path Basic Ym9iOnB3", requested)
+}
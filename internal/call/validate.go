@@ -0,0 +1,568 @@
+package call
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Problem severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// A Problem is an issue found in a config file. Errors prevent the file
+// from being run, warnings point at fields that are likely mistakes
+type Problem struct {
+	File     string
+	Line     int    // 0 when the position is unknown
+	Column   int    // 0 when the position is unknown
+	Entry    int    // index of the Config entry, -1 for the whole file
+	Name     string // name of the Config entry
+	Field    string // dotted path of the field, such as steps.0.url
+	Severity string
+	Message  string
+}
+
+// String formats a Problem the way compilers do, so editors can jump
+// to its position
+func (p Problem) String() string {
+	var b strings.Builder
+	b.WriteString(p.File)
+	if p.Line > 0 {
+		fmt.Fprintf(&b, ":%d:%d", p.Line, p.Column)
+	}
+	fmt.Fprintf(&b, ": %s: ", p.Severity)
+	if p.Entry >= 0 {
+		fmt.Fprintf(&b, "entry %d", p.Entry)
+		if p.Name != "" {
+			fmt.Fprintf(&b, " (%s)", p.Name)
+		}
+		b.WriteString(": ")
+	}
+	if p.Field != "" {
+		b.WriteString(p.Field + ": ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// HasErrors tells whether any of the problems is an error
+func HasErrors(problems []Problem) bool {
+	for _, problem := range problems {
+		if problem.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// position of a key in a config file
+type position struct {
+	line, column int
+}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// ValidateConfigFile reports every problem of a config file and of the
+// files it includes, instead of stopping at the first one like
+// BuildCallsFromConfigFile does: syntax errors, invalid values, unknown
+// keys, ignored fields and suspicious values. The returned error is for
+// files that cannot be read at all
+func ValidateConfigFile(path string, options LoadOptions) (problems []Problem, err error) {
+	raw, err := readConfigFile(path)
+	if err != nil {
+		return
+	}
+	format, err := detectFormat(path, raw)
+	if err != nil {
+		return
+	}
+	fileProblem := func(file string, pos position, err error) []Problem {
+		return []Problem{{
+			File: file, Line: pos.line, Column: pos.column, Entry: -1,
+			Severity: SeverityError, Message: err.Error(),
+		}}
+	}
+
+	doc, err := decodeDocument(raw, format)
+	if err != nil {
+		return fileProblem(path, syntaxErrorPosition(raw, err), err), nil
+	}
+	resolved, err := resolveDocument(path, doc, make(map[string]bool))
+	if err != nil {
+		var includeErr *includeError
+		if errors.As(err, &includeErr) {
+			return fileProblem(includeErr.file, includeErr.pos, includeErr.err), nil
+		}
+		return fileProblem(path, position{}, err), nil
+	}
+	env, err := applyEnvironment(resolved, options)
+	if err != nil {
+		return fileProblem(path, position{}, err), nil
+	}
+	// entries are expanded and decoded one by one, so a broken entry
+	// hides neither the problems of the others nor where it breaks
+	configs := make([]Config, len(resolved.cases))
+	broken := make([][]fieldError, len(resolved.cases))
+	for i, entry := range resolved.cases {
+		configs[i], broken[i] = decodeEntry(entry, env.Variables)
+	}
+
+	// positions of the keys of every file, indexed when first needed
+	files := map[string]map[string]position{path: indexPositions(raw, format)}
+	positions := func(file string) map[string]position {
+		if _, ok := files[file]; !ok {
+			raw, _ := ioutil.ReadFile(file)
+			format, _ := detectFormat(file, raw)
+			files[file] = indexPositions(raw, format)
+		}
+		return files[file]
+	}
+	entryPath := func(file string, index int) string {
+		if _, ok := positions(file)["cases"]; ok {
+			return "cases." + strconv.Itoa(index)
+		}
+		return strconv.Itoa(index)
+	}
+	entryIndexes := make(map[origin]int)
+	for i, o := range resolved.origins {
+		entryIndexes[o] = i
+	}
+
+	report := func(file string, entry int, severity string, errs []fieldError) {
+		name, root := "", ""
+		if entry >= 0 {
+			name = configs[entry].Name
+			root = entryPath(file, resolved.origins[entry].index)
+		}
+		for _, e := range errs {
+			pos := lookupPosition(positions(file), root, e.field)
+			problems = append(problems, Problem{
+				File: file, Line: pos.line, Column: pos.column, Entry: entry, Name: name,
+				Field: e.field, Severity: severity, Message: e.err.Error(),
+			})
+		}
+	}
+	for _, key := range resolved.unknown {
+		entry, field := -1, key.path
+		parts := strings.SplitN(strings.TrimPrefix(key.path, "cases."), ".", 3)
+		if index, err := strconv.Atoi(parts[0]); err == nil && len(parts) > 1 {
+			if i, ok := entryIndexes[origin{key.file, index}]; ok && i < len(configs) {
+				entry, field = i, strings.TrimPrefix(key.path, entryPath(key.file, index)+".")
+			}
+		}
+		report(key.file, entry, SeverityWarning, []fieldError{{field, errors.New("unknown key")}})
+	}
+	for i := range configs {
+		c := &configs[i]
+		if broken[i] != nil {
+			report(resolved.origins[i].file, i, SeverityError, broken[i])
+			continue
+		}
+		report(resolved.origins[i].file, i, SeverityError, c.validate())
+		report(resolved.origins[i].file, i, SeverityWarning, c.warnings())
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Entry != problems[j].Entry {
+			return problems[i].Entry < problems[j].Entry
+		}
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+		return problems[i].Column < problems[j].Column
+	})
+	return problems, nil
+}
+
+// decodeEntry expands an entry of a config document and decodes it,
+// returning the key that stopped it when either fails
+func decodeEntry(entry interface{}, variables map[string]string) (c Config, errs []fieldError) {
+	expanded, err := expand(entry, variables)
+	if err != nil {
+		if object, ok := entry.(map[string]interface{}); ok {
+			c.Name, _ = object["name"].(string)
+		}
+		if e, ok := err.(fieldError); ok {
+			return c, []fieldError{e}
+		}
+		return c, []fieldError{{"", err}}
+	}
+	converted, err := json.Marshal(expanded)
+	if err != nil {
+		return c, []fieldError{{"", err}}
+	}
+	if err = json.Unmarshal(converted, &c); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return c, []fieldError{{typeErr.Field, fmt.Errorf("%s value where %s is expected", typeErr.Value, typeErr.Type)}}
+		}
+		return c, []fieldError{{"", err}}
+	}
+	return c, nil
+}
+
+// documentUnknownKeys returns the paths of the keys of a decoded config
+// document, defaults and environment overrides included, that match no
+// field
+func documentUnknownKeys(file string, doc interface{}) (keys []fileKey) {
+	add := func(paths []string) {
+		for _, path := range paths {
+			keys = append(keys, fileKey{file, path})
+		}
+	}
+	object, ok := doc.(map[string]interface{})
+	if !ok {
+		add(unknownKeys(doc, reflect.TypeOf([]Config{}), ""))
+		return
+	}
+	add(unknownKeys(doc, reflect.TypeOf(document{}), ""))
+	add(unknownKeys(object["defaults"], reflect.TypeOf(Config{}), "defaults"))
+	environments, _ := object["environments"].(map[string]interface{})
+	names := make([]string, 0, len(environments))
+	for name := range environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if env, ok := environments[name].(map[string]interface{}); ok {
+			add(unknownKeys(env["override"], reflect.TypeOf(Config{}), "environments."+name+".override"))
+		}
+	}
+	return
+}
+
+// indexPositions maps the key paths of a config file to their position
+func indexPositions(raw []byte, format string) map[string]position {
+	switch format {
+	case FormatJSON:
+		return indexJSON(raw)
+	case FormatYAML:
+		return indexYAML(raw)
+	case FormatTOML:
+		return indexTOML(raw)
+	}
+	return nil
+}
+
+// warnings returns the fields of a validated Config that are ignored or
+// hold suspicious values
+func (c *Config) warnings() (warns []fieldError) {
+	ignored := func(field, reason string) {
+		warns = append(warns, fieldError{field, errors.New("ignored " + reason)})
+	}
+	if c.Host != "" {
+		ignored("host", "field")
+	}
+	if c.Form != "" {
+		ignored("form", "field")
+	}
+	if len(c.PostForm) > 0 {
+		ignored("postform", "field")
+	}
+	if len(c.Steps) > 0 || len(c.Mix) > 0 {
+		reason := "with steps, each step has its own"
+		if len(c.Mix) > 0 {
+			reason = "with mix, each entry has its own"
+		}
+		if c.URL != "" {
+			ignored("url", reason)
+		}
+		if c.Method != "" {
+			ignored("method", reason)
+		}
+		if c.Body != "" {
+			ignored("body", reason)
+		}
+	} else if c.Body != "" && (c.Method == http.MethodGet || c.Method == http.MethodHead) {
+		warns = append(warns, fieldError{"body", fmt.Errorf("body sent with a %s request", c.Method)})
+	}
+	if c.Func != "" && !strings.Contains(c.Body, "%") {
+		ignored("func", "as body has no verb to hold its result")
+	}
+	if c.ConcurrentAttempts > c.Attempts && c.Attempts > 0 {
+		warns = append(warns, fieldError{"concurrent", fmt.Errorf(
+			"%d concurrent workers for %d attempts, only %d will be busy",
+			c.ConcurrentAttempts, c.Attempts, c.Attempts)})
+	}
+	if c.Attempts < 0 {
+		warns = append(warns, fieldError{"attempts", errors.New("negative attempts make no request")})
+	}
+	return
+}
+
+// unknownKeys returns the paths of the object keys of a decoded
+// document that match no JSON field of t
+func unknownKeys(node interface{}, t reflect.Type, path string) (keys []string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fieldType, ok := lookupField(fields, name)
+			if !ok {
+				keys = append(keys, joinPath(path, name))
+				continue
+			}
+			keys = append(keys, unknownKeys(object[name], fieldType, joinPath(path, name))...)
+		}
+	case reflect.Slice:
+		list, _ := node.([]interface{})
+		for i, item := range list {
+			keys = append(keys, unknownKeys(item, t.Elem(), joinPath(path, strconv.Itoa(i)))...)
+		}
+	}
+	return
+}
+
+// jsonFields maps the JSON names of the fields of a struct to their
+// types, including the fields of embedded structs
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			for name, fieldType := range jsonFields(field.Type) {
+				fields[name] = fieldType
+			}
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// lookupField finds a field the way encoding/json does, preferring an
+// exact match over a case insensitive one
+func lookupField(fields map[string]reflect.Type, name string) (reflect.Type, bool) {
+	if fieldType, ok := fields[name]; ok {
+		return fieldType, true
+	}
+	for fieldName, fieldType := range fields {
+		if strings.EqualFold(fieldName, name) {
+			return fieldType, true
+		}
+	}
+	return nil, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// lookupPosition returns the position of a field of an entry, falling
+// back to its closest known parent
+func lookupPosition(positions map[string]position, entry, field string) position {
+	path := joinPath(entry, field)
+	for {
+		if pos, ok := positions[path]; ok {
+			return pos
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			return position{}
+		}
+		path = path[:i]
+	}
+}
+
+// indexJSON maps the path of every key and array item of a valid JSON
+// document to its position
+func indexJSON(raw []byte) map[string]position {
+	positions := make(map[string]position)
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	var walk func(path string) error
+	walk = func(path string) error {
+		positions[path] = offsetPosition(raw, skipSeparators(raw, dec.InputOffset()))
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'):
+			for dec.More() {
+				start := skipSeparators(raw, dec.InputOffset())
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child := joinPath(path, key.(string))
+				if err = walk(child); err != nil {
+					return err
+				}
+				positions[child] = offsetPosition(raw, start)
+			}
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err = walk(joinPath(path, strconv.Itoa(i))); err != nil {
+					return err
+				}
+			}
+		default:
+			return nil
+		}
+		_, err = dec.Token()
+		return err
+	}
+	walk("")
+	return positions
+}
+
+// skipSeparators returns the offset of the next token after offset
+func skipSeparators(raw []byte, offset int64) int64 {
+	for offset < int64(len(raw)) && strings.IndexByte(" \t\r\n,:", raw[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// offsetPosition converts a byte offset to a line and column
+func offsetPosition(raw []byte, offset int64) position {
+	if offset > int64(len(raw)) {
+		offset = int64(len(raw))
+	}
+	before := raw[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	return position{line, int(offset) - bytes.LastIndexByte(before, '\n')}
+}
+
+// indexYAML maps the path of every key and sequence item of a valid
+// YAML document to its position
+func indexYAML(raw []byte) map[string]position {
+	var root yaml.Node
+	yaml.Unmarshal(raw, &root)
+	positions := make(map[string]position)
+	var walk func(node *yaml.Node, path string)
+	walk = func(node *yaml.Node, path string) {
+		positions[path] = position{node.Line, node.Column}
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i]
+				child := joinPath(path, key.Value)
+				walk(node.Content[i+1], child)
+				positions[child] = position{key.Line, key.Column}
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				walk(item, joinPath(path, strconv.Itoa(i)))
+			}
+		}
+	}
+	walk(&root, "")
+	return positions
+}
+
+// indexTOML maps the path of the tables and keys of a TOML document to
+// their position. It goes line by line, so keys of inline tables are
+// not indexed and resolve to their parent
+func indexTOML(raw []byte) map[string]position {
+	positions := make(map[string]position)
+	current := make(map[string]int) // last index of each array of tables
+	resolve := func(name string) string {
+		parts := strings.Split(name, ".")
+		path := make([]string, 0, 2*len(parts))
+		for i, part := range parts {
+			path = append(path, part)
+			if index, ok := current[strings.Join(parts[:i+1], ".")]; ok && i < len(parts)-1 {
+				path = append(path, strconv.Itoa(index))
+			}
+		}
+		return strings.Join(path, ".")
+	}
+	table := ""
+	for n, line := range strings.Split(string(raw), "\n") {
+		trimmed := strings.TrimSpace(line)
+		pos := position{n + 1, len(line) - len(strings.TrimLeft(line, " \t")) + 1}
+		switch {
+		case strings.HasPrefix(trimmed, "[["):
+			name := strings.TrimSpace(strings.Trim(trimmed[:strings.Index(trimmed, "]]")+2], "[]"))
+			index, ok := current[name]
+			if ok {
+				index++
+			}
+			current[name] = index
+			for nested := range current {
+				if strings.HasPrefix(nested, name+".") {
+					delete(current, nested)
+				}
+			}
+			if _, ok := positions[resolve(name)]; !ok {
+				positions[resolve(name)] = pos
+			}
+			table = resolve(name) + "." + strconv.Itoa(index)
+			positions[table] = pos
+		case strings.HasPrefix(trimmed, "["):
+			table = resolve(strings.TrimSpace(strings.Trim(trimmed[:strings.Index(trimmed, "]")+1], "[]")))
+			positions[table] = pos
+		case strings.Contains(trimmed, "=") && !strings.HasPrefix(trimmed, "#"):
+			key := strings.Trim(strings.TrimSpace(trimmed[:strings.Index(trimmed, "=")]), `"'`)
+			positions[joinPath(table, key)] = pos
+		}
+	}
+	return positions
+}
+
+// syntaxErrorPosition extracts the position of a decoding error
+func syntaxErrorPosition(raw []byte, err error) position {
+	var (
+		jsonErr *json.SyntaxError
+		typeErr *json.UnmarshalTypeError
+		tomlErr toml.ParseError
+		yamlErr *yaml.TypeError
+	)
+	switch {
+	case errors.As(err, &jsonErr):
+		// the offset is past the offending character
+		return offsetPosition(raw, jsonErr.Offset-1)
+	case errors.As(err, &typeErr):
+		return offsetPosition(raw, typeErr.Offset)
+	case errors.As(err, &tomlErr):
+		return position{tomlErr.Position.Line, tomlErr.Position.Col}
+	case errors.As(err, &yamlErr) && len(yamlErr.Errors) > 0:
+		if groups := yamlLinePattern.FindStringSubmatch(yamlErr.Errors[0]); groups != nil {
+			line, _ := strconv.Atoi(groups[1])
+			return position{line, 1}
+		}
+	}
+	// YAML syntax errors often name the line a construct starts on
+	// rather than the offending one, so they get no position
+	return position{}
+}
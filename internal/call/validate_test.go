@@ -0,0 +1,159 @@
+package call
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeConfigFile writes a config file in a temporary directory
+func writeConfigFile(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "call-it")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, name)
+	if err = ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// problemStrings formats problems without their directory
+func problemStrings(problems []Problem) (lines []string) {
+	for _, problem := range problems {
+		problem.File = filepath.Base(problem.File)
+		lines = append(lines, problem.String())
+	}
+	return
+}
+
+func TestValidateConfigFileReportsEveryProblem(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `[
+  {
+    "name": "ok",
+    "method": "GET",
+    "url": "http://www.globo.com"
+  },
+  {
+    "method": "FETCH",
+    "url": "http://www.globo.com",
+    "attempts": 2,
+    "concurrent": 5,
+    "timeout": 3
+  },
+  {
+    "name": "flow",
+    "url": "http://www.globo.com",
+    "steps": [
+      {"url": "http://www.globo.com/login", "method": "POST"},
+      {"method": "GET",
+       "checks": [{"type": "status"}]}
+    ]
+  }
+]`)
+	problems, err := ValidateConfigFile(path, LoadOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"config.json:7:3: error: entry 1: name: Request Config name cannot be nil",
+		"config.json:8:5: error: entry 1: method: Method not allowed",
+		"config.json:11:5: warning: entry 1: concurrent: 5 concurrent workers for 2 attempts, only 2 will be busy",
+		"config.json:12:5: warning: entry 1: timeout: unknown key",
+		"config.json:16:5: warning: entry 2 (flow): url: ignored with steps, each step has its own",
+		"config.json:19:7: error: entry 2 (flow): steps.1.url: Scenario step URL cannot be empty",
+		"config.json:20:19: error: entry 2 (flow): steps.1.checks.0.status: invalid check: status list is empty",
+	}, problemStrings(problems))
+	assert.True(t, HasErrors(problems))
+}
+
+func TestValidateConfigFileKeepsGoingPastBrokenEntries(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `[
+  {"name": "typed", "method": "GET", "url": "http://www.globo.com",
+    "attempts": "ten"},
+  {"name": "undefined", "method": "GET", "url": "http://www.globo.com",
+    "header": {"X-Token": ["${CALL_IT_UNDEFINED_TOKEN}"]}},
+  {"name": "step", "steps": [{"url": "http://www.globo.com"}, {"url": 3}]},
+  {"name": "", "method": "GET", "url": "http://www.globo.com"}
+]`)
+	problems, err := ValidateConfigFile(path, LoadOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"config.json:3:5: error: entry 0 (typed): attempts: string value where int is expected",
+		"config.json:5:28: error: entry 1 (undefined): header.X-Token.0: undefined environment variable: CALL_IT_UNDEFINED_TOKEN",
+		"config.json:6:64: error: entry 2 (step): steps.1.url: number value where string is expected",
+		"config.json:7:4: error: entry 3: name: Request Config name cannot be nil",
+	}, problemStrings(problems))
+}
+
+func TestValidateConfigFileWithoutProblems(t *testing.T) {
+	problems, err := ValidateConfigFile("../../examples/scenario.json", LoadOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, problemStrings(problems))
+	assert.False(t, HasErrors(problems))
+}
+
+func TestValidateConfigFilePositionsAcrossFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "yaml",
+			file:    "config.yaml",
+			content: "- name: yaml\n  url: http://www.globo.com\n  method: GET\n  thinktime: 1\n  mix:\n    - url: http://www.globo.com\n      weight: -1\n",
+			want: []string{
+				"config.yaml:2:3: warning: entry 0 (yaml): url: ignored with mix, each entry has its own",
+				"config.yaml:3:3: warning: entry 0 (yaml): method: ignored with mix, each entry has its own",
+				"config.yaml:4:3: warning: entry 0 (yaml): thinktime: unknown key",
+				"config.yaml:7:7: error: entry 0 (yaml): mix.0.weight: mix weight cannot be negative",
+			},
+		},
+		{
+			name:    "toml",
+			file:    "config.toml",
+			content: "[[cases]]\nname = \"first\"\nurl = \"http://www.globo.com\"\n\n[[cases]]\nname = \"second\"\n\n[[cases.steps]]\nurl = \"http://www.globo.com\"\n\n[[cases.steps]]\nmethod = \"GET\"\n",
+			want: []string{
+				"config.toml:1:1: error: entry 0 (first): method: Method not allowed",
+				"config.toml:11:1: error: entry 1 (second): steps.1.url: Scenario step URL cannot be empty",
+			},
+		},
+		{
+			name:    "json syntax error",
+			file:    "config.json",
+			content: "[\n  {\"name\": \"broken\",}\n]",
+			want:    []string{"config.json:2:21: error: invalid character '}' looking for beginning of object key string"},
+		},
+		{
+			name:    "yaml syntax error",
+			file:    "config.yaml",
+			content: "- name: broken\n  url: [http://www.globo.com\n",
+			want:    []string{"config.yaml: error: yaml: line 1: did not find expected ',' or ']'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, err := ValidateConfigFile(writeConfigFile(t, tt.file, tt.content), LoadOptions{})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, problemStrings(problems))
+		})
+	}
+}
+
+func TestValidateConfigFileWithMissingFile(t *testing.T) {
+	_, err := ValidateConfigFile("missing.json", LoadOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestBuildCallsFromConfigFileNamesTheFailingEntry(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `[{"name": "ok", "method": "GET", "url": "http://www.globo.com"}, {"name": "bad", "method": "FETCH", "url": "http://www.globo.com"}]`)
+	_, err := BuildCallsFromConfigFile(path)
+	assert.EqualError(t, err, "entry 1 (bad): Method not allowed")
+	assert.True(t, errors.Is(err, ErrMethodNotAllowed))
+}
@@ -0,0 +1,400 @@
+package call
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultWatchInterval is the time between two checks of watched files
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// Metrics compared between two runs of a case
+const (
+	MetricAvg     = "AVG"
+	MetricMin     = "MIN"
+	MetricMax     = "MAX"
+	MetricElapsed = "ELAPSED"
+	MetricSuccess = "SUCCESS"
+)
+
+// A Watcher runs the cases of a config file, then runs again the cases
+// affected by every change of the file or of the files it depends on:
+// included config files and secret files
+type Watcher struct {
+	Interval time.Duration // between two checks of the files, DefaultWatchInterval when 0
+	History  *History      // keeps the result of every case that runs, none when nil
+
+	path    string
+	options LoadOptions
+	stamps  map[string]fileStamp   // state of every watched file
+	cases   map[string]watchedCase // last run of every case, by key
+	changed []string               // files changed since the last run
+}
+
+// WatchRun holds the results of the cases a Watcher ran
+type WatchRun struct {
+	changed  []string  // files changed since the previous run
+	results  []Result  // results of the cases that ran
+	previous []*Result // previous result of each case, nil on its first run
+	err      error     // error loading the config, nothing ran
+
+	historyErr error // results the history could not keep
+}
+
+// Delta compares a metric of two runs of a case
+type Delta struct {
+	metric   string
+	status   string // status code the metric is about, empty for all the responses
+	previous float64
+	current  float64
+}
+
+// fileStamp tells whether a file changed
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// watchedCase is the last run of a case
+type watchedCase struct {
+	fingerprint string // resolved Config the case ran with
+	result      Result
+}
+
+// NewWatcher returns a Watcher of a config file. The standard input
+// cannot be watched
+func NewWatcher(path string, options LoadOptions) (*Watcher, error) {
+	if path == StdinPath {
+		return nil, ErrWatchStdin
+	}
+	return &Watcher{path: path, options: options}, nil
+}
+
+// Watch runs the cases of a config file and runs again the affected
+// ones after each change, until stop is closed. Every run is reported,
+// including the ones failing to load the config, which keep watching
+func Watch(path string, options LoadOptions, stop <-chan struct{}, report func(WatchRun)) error {
+	watcher, err := NewWatcher(path, options)
+	if err != nil {
+		return err
+	}
+	for {
+		report(watcher.Run())
+		if !watcher.Wait(stop) {
+			return nil
+		}
+	}
+}
+
+// Run loads the config file and runs the cases that are new or whose
+// resolved Config changed since the last run, every case the first
+// time. Cases are keyed by name, or by position when they have none.
+// The suite settings of the file apply to the cases that run
+func (w *Watcher) Run() (run WatchRun) {
+	run.changed, w.changed = w.changed, nil
+	files, err := configDependencies(w.path, w.options)
+	if err == nil {
+		var suite Suite
+		if suite, err = BuildSuite(w.path, w.options); err == nil {
+			w.stamps = stampFiles(files)
+			w.runAffected(suite, &run)
+			return
+		}
+	}
+	// the files depended on are unknown, so the last ones known stay
+	// watched, waiting for a fix
+	known := []string{w.path}
+	for file := range w.stamps {
+		known = append(known, file)
+	}
+	w.stamps = stampFiles(known)
+	run.err = err
+	return
+}
+
+// runAffected runs the calls of a suite affected by the last changes
+func (w *Watcher) runAffected(suite Suite, run *WatchRun) {
+	cases := make(map[string]watchedCase)
+	var (
+		affected []ConcurrentCall
+		keys     []string
+	)
+	caseKeys := caseKeys(suite.calls)
+	for i, call := range suite.calls {
+		key := caseKeys[i]
+		fingerprint := fingerprintConfig(call.config)
+		last, ok := w.cases[key]
+		if ok && last.fingerprint == fingerprint {
+			cases[key] = last
+			continue
+		}
+		var previous *Result
+		if ok {
+			result := last.result
+			previous = &result
+		}
+		affected = append(affected, call)
+		keys = append(keys, key)
+		run.previous = append(run.previous, previous)
+		cases[key] = watchedCase{fingerprint: fingerprint}
+	}
+
+	suite.calls, suite.History = affected, w.History
+	run.results = suite.Run()
+	run.historyErr = suite.GetHistoryErr()
+	for i, key := range keys {
+		watched := cases[key]
+		watched.result = run.results[i]
+		cases[key] = watched
+	}
+	w.cases = cases
+}
+
+// Wait checks the watched files until some change, returning true, or
+// stop is closed, returning false. Changes are gathered until files
+// stay the same for an interval, so a save writing several times
+// triggers a single run
+func (w *Watcher) Wait(stop <-chan struct{}) bool {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	changed := make(map[string]bool)
+	for {
+		select {
+		case <-stop:
+			return false
+		case <-ticker.C:
+		}
+		files := w.changedFiles()
+		if len(files) == 0 && len(changed) > 0 {
+			for file := range changed {
+				w.changed = append(w.changed, file)
+			}
+			sort.Strings(w.changed)
+			return true
+		}
+		for _, file := range files {
+			changed[file] = true
+			w.stamps[file] = stampFile(file)
+		}
+	}
+}
+
+// changedFiles returns the watched files whose stamp changed
+func (w *Watcher) changedFiles() (files []string) {
+	for file, stamp := range w.stamps {
+		if stampFile(file) != stamp {
+			files = append(files, file)
+		}
+	}
+	return
+}
+
+// GetChanged returns the files changed since the previous run
+func (r *WatchRun) GetChanged() []string {
+	return r.changed
+}
+
+// GetResults returns the results of the cases that ran
+func (r *WatchRun) GetResults() []Result {
+	return r.results
+}
+
+// GetPrevious returns the previous result of each case that ran, nil
+// for the cases that ran for the first time
+func (r *WatchRun) GetPrevious() []*Result {
+	return r.previous
+}
+
+// GetHistoryErr returns why the history could not keep results of the
+// run, nil when it kept them all
+func (r *WatchRun) GetHistoryErr() error {
+	return r.historyErr
+}
+
+// GetErr returns the error loading the config, if any
+func (r *WatchRun) GetErr() error {
+	return r.err
+}
+
+// CompareResults returns the deltas of the main metrics of two runs of
+// a case, MetricSuccess being the share of responses below 400
+func CompareResults(previous, current Result) []Delta {
+	return []Delta{
+		{metric: MetricAvg, previous: previous.avgExecution, current: current.avgExecution},
+		{metric: MetricMin, previous: previous.minExecution, current: current.minExecution},
+		{metric: MetricMax, previous: previous.maxExecution, current: current.maxExecution},
+		{metric: MetricElapsed, previous: previous.totalExecution, current: current.totalExecution},
+		{metric: MetricSuccess, previous: successRatio(previous), current: successRatio(current)},
+	}
+}
+
+// GetMetric returns the name of the metric compared
+func (d *Delta) GetMetric() string {
+	return d.metric
+}
+
+// GetPrevious returns the value of the previous run
+func (d *Delta) GetPrevious() float64 {
+	return d.previous
+}
+
+// GetCurrent returns the value of the current run
+func (d *Delta) GetCurrent() float64 {
+	return d.current
+}
+
+// GetChange returns the current value minus the previous one
+func (d *Delta) GetChange() float64 {
+	return d.current - d.previous
+}
+
+// GetStatus returns the status code the metric is about, empty when
+// it is about all the responses
+func (d *Delta) GetStatus() string {
+	return d.status
+}
+
+// GetChangeRatio returns the change relative to the previous value,
+// infinite when the previous value is 0 and the current one is not
+func (d *Delta) GetChangeRatio() float64 {
+	if d.previous == 0 {
+		if d.current == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return d.GetChange() / d.previous
+}
+
+// Format formats a value of the metric, a time, a ratio or a rate
+func (d *Delta) Format(value float64) string {
+	switch d.metric {
+	case MetricSuccess, MetricErrors:
+		return formatRatio(value)
+	case MetricRPS:
+		return formatRate(value)
+	}
+	return formatTime(value)
+}
+
+// FormatChange formats the change of the metric, always signed
+func (d *Delta) FormatChange() string {
+	change := d.GetChange()
+	if change < 0 {
+		return d.Format(change)
+	}
+	return "+" + d.Format(change)
+}
+
+// FormatChangeRatio formats the relative change of the metric as a
+// signed percentage, "new" when the previous value is 0
+func (d *Delta) FormatChangeRatio() string {
+	ratio := d.GetChangeRatio()
+	if math.IsInf(ratio, 1) {
+		return "new"
+	}
+	return fmt.Sprintf("%+.2f%%", ratio*100)
+}
+
+// successRatio returns the share of the responses of a result whose
+// status is below 400, failed requests included
+func successRatio(result Result) float64 {
+	total, succeeded := 0, 0
+	for status, benchmark := range result.status {
+		total += benchmark.total
+		if status > 0 && status < 400 {
+			succeeded += benchmark.total
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(succeeded) / float64(total)
+}
+
+// configDependencies returns the files read to load a config file: the
+// config files and the secret files of their entries
+func configDependencies(path string, options LoadOptions) (files []string, err error) {
+	raw, err := readConfigFile(path)
+	if err != nil {
+		return
+	}
+	format, err := detectFormat(path, raw)
+	if err != nil {
+		return
+	}
+	doc, err := decodeDocument(raw, format)
+	if err != nil {
+		return
+	}
+	resolved, err := resolveDocument(path, doc, make(map[string]bool))
+	if err != nil {
+		return
+	}
+	env, err := selectEnvironment(resolved, options.Env)
+	if err != nil {
+		return
+	}
+	files = append(files, resolved.files...)
+	files = append(files, secretFiles(resolved.cases, env.Variables)...)
+	files = append(files, secretFiles(env.Override, env.Variables)...)
+	return
+}
+
+// stampFiles returns the stamp of every file
+func stampFiles(files []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(files))
+	for _, file := range files {
+		stamps[file] = stampFile(file)
+	}
+	return stamps
+}
+
+// stampFile returns the stamp of a file, missing files included
+func stampFile(file string) fileStamp {
+	info, err := os.Stat(file)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, size: info.Size(), modTime: info.ModTime()}
+}
+
+// caseKeys identify the cases of a config file across runs: by name,
+// numbered when several share it, or by position when unnamed
+func caseKeys(calls []ConcurrentCall) []string {
+	keys := make([]string, len(calls))
+	seen := make(map[string]int)
+	for i, call := range calls {
+		key := call.config.Name
+		if key == "" {
+			keys[i] = "#" + strconv.Itoa(i+1)
+			continue
+		}
+		seen[key]++
+		if seen[key] > 1 {
+			key += " #" + strconv.Itoa(seen[key])
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+// fingerprintConfig returns a resolved Config as a comparable string
+func fingerprintConfig(c Config) string {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Sprintf("%+v", c)
+	}
+	return c.environment + string(raw)
+}
@@ -0,0 +1,159 @@
+package call
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rewriteFile replaces the content of a watched file
+func rewriteFile(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitChange waits for a watcher to notice a change, failing after a second
+func waitChange(t *testing.T, watcher *Watcher) bool {
+	stop := make(chan struct{})
+	timer := time.AfterFunc(time.Second, func() { close(stop) })
+	defer timer.Stop()
+	return watcher.Wait(stop)
+}
+
+func TestWatcherRunsAffectedCases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	cases := `[
+  {"name": "a", "method": "GET", "url": "%[1]s/a", "attempts": 2, "concurrent": 1},
+  {"name": "b", "method": "GET", "url": "%[1]s/b", "attempts": %[2]d, "concurrent": 1}
+]`
+	path := writeConfigFile(t, "config.json", fmt.Sprintf(cases, server.URL, 2))
+	watcher, err := NewWatcher(path, LoadOptions{})
+	assert.Nil(t, err)
+	watcher.Interval = 10 * time.Millisecond
+	watcher.History, err = OpenHistory(t.TempDir())
+	assert.Nil(t, err)
+
+	run := watcher.Run()
+	assert.Nil(t, run.GetErr())
+	assert.Nil(t, run.GetHistoryErr())
+	assert.Len(t, run.GetResults(), 2)
+	assert.Equal(t, []*Result{nil, nil}, run.GetPrevious())
+	runs, _, err := watcher.History.List("")
+	assert.Nil(t, err)
+	assert.Len(t, runs, 2)
+
+	rewriteFile(t, path, fmt.Sprintf(cases, server.URL, 3))
+	assert.True(t, waitChange(t, watcher))
+	run = watcher.Run()
+	assert.Equal(t, []string{path}, run.GetChanged())
+	assert.Len(t, run.GetResults(), 1)
+	assert.Equal(t, "b", run.GetResults()[0].GetName())
+	assert.Equal(t, 3, run.GetResults()[0].status[200].total)
+	assert.Equal(t, 2, run.GetPrevious()[0].status[200].total)
+
+	rewriteFile(t, path, fmt.Sprintf(cases, server.URL, 3)+"\n")
+	assert.True(t, waitChange(t, watcher))
+	run = watcher.Run()
+	assert.Nil(t, run.GetErr())
+	assert.Empty(t, run.GetResults())
+}
+
+func TestWatcherTellsDuplicateNamesApart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	cases := `[
+  {"name": "a", "method": "GET", "url": "%[1]s/a", "attempts": 2, "concurrent": 1},
+  {"name": "a", "method": "GET", "url": "%[1]s/b", "attempts": %[2]d, "concurrent": 1}
+]`
+	path := writeConfigFile(t, "config.json", fmt.Sprintf(cases, server.URL, 2))
+	watcher, err := NewWatcher(path, LoadOptions{})
+	assert.Nil(t, err)
+	watcher.Interval = 10 * time.Millisecond
+	run := watcher.Run()
+	assert.Len(t, run.GetResults(), 2)
+
+	rewriteFile(t, path, fmt.Sprintf(cases, server.URL, 3))
+	assert.True(t, waitChange(t, watcher))
+	run = watcher.Run()
+	assert.Len(t, run.GetResults(), 1)
+	assert.Equal(t, "/b", run.GetResults()[0].URL.Path)
+	assert.Equal(t, 2, run.GetPrevious()[0].status[200].total)
+}
+
+func TestWatcherWatchesSecretFilesAndIncludes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	dir := writeConfigFiles(t, map[string]string{
+		"config.json": `{"include": "common.json", "cases": [{"name": "token", "method": "GET", "url": "` + server.URL + `", "attempts": 1, "header": {"X-Token": [{"secret_file": "${CALL_IT_SECRETS}/token"}]}}]}`,
+		"common.json": `[{"name": "common", "method": "GET", "url": "` + server.URL + `", "attempts": 1}]`,
+		"token":       "first-token",
+	})
+	os.Setenv("CALL_IT_SECRETS", dir)
+	defer os.Unsetenv("CALL_IT_SECRETS")
+	path := filepath.Join(dir, "config.json")
+
+	files, err := configDependencies(path, LoadOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{path, filepath.Join(dir, "common.json"), filepath.Join(dir, "token")}, files)
+
+	watcher, _ := NewWatcher(path, LoadOptions{})
+	watcher.Interval = 10 * time.Millisecond
+	run := watcher.Run()
+	assert.Len(t, run.GetResults(), 2)
+
+	rewriteFile(t, filepath.Join(dir, "token"), "second-token")
+	assert.True(t, waitChange(t, watcher))
+	run = watcher.Run()
+	assert.Equal(t, []string{filepath.Join(dir, "token")}, run.GetChanged())
+	assert.Len(t, run.GetResults(), 1)
+	assert.Equal(t, "token", run.GetResults()[0].GetName())
+}
+
+func TestWatcherKeepsWatchingBrokenConfigs(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `[{"name": "a", "method": "GET", "url": `)
+	watcher, _ := NewWatcher(path, LoadOptions{})
+	watcher.Interval = 10 * time.Millisecond
+	run := watcher.Run()
+	assert.NotNil(t, run.GetErr())
+	assert.Empty(t, run.GetResults())
+
+	rewriteFile(t, path, `[]`)
+	assert.True(t, waitChange(t, watcher))
+	run = watcher.Run()
+	assert.Nil(t, run.GetErr())
+
+	stop := make(chan struct{})
+	close(stop)
+	assert.False(t, watcher.Wait(stop))
+
+	_, err := NewWatcher(StdinPath, LoadOptions{})
+	assert.Equal(t, ErrWatchStdin, err)
+}
+
+func TestCompareResults(t *testing.T) {
+	previous := Result{avgExecution: 0.5, minExecution: 0.1, maxExecution: 1, totalExecution: 2,
+		status: map[int]StatusCodeBenchmark{200: {total: 3}, 500: {total: 1}}}
+	current := Result{avgExecution: 0.25, minExecution: 0.1, maxExecution: 1.5, totalExecution: 1,
+		status: map[int]StatusCodeBenchmark{200: {total: 4}}}
+
+	var got []string
+	for _, delta := range CompareResults(previous, current) {
+		got = append(got, fmt.Sprintf("%s %s %s %s", delta.GetMetric(), delta.Format(delta.GetPrevious()), delta.Format(delta.GetCurrent()), delta.FormatChange()))
+	}
+	assert.Equal(t, []string{
+		"AVG 0.50s 0.25s -0.25s",
+		"MIN 0.10s 0.10s +0.00s",
+		"MAX 1.00s 1.50s +0.50s",
+		"ELAPSED 2.00s 1.00s -1.00s",
+		"SUCCESS 75.00% 100.00% +25.00%",
+	}, got)
+}
@@ -1,12 +1,14 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -15,6 +17,19 @@ import (
 	"github.com/pedrolopesme/call-it/internal/version"
 )
 
+// writeClipboard copies text to the system clipboard
+var writeClipboard = clipboard.WriteAll
+
+// saveRun keeps the results of a call in the run history
+var saveRun = func(config call.Config, result call.Result) error {
+	history, err := call.OpenHistory("")
+	if err != nil {
+		return err
+	}
+	_, err = history.Save(config, result)
+	return err
+}
+
 // ViewState represents the current view of the TUI
 type ViewState int
 
@@ -23,6 +38,7 @@ const (
 	CurlInputView
 	LoadingView
 	ResultsView
+	WatchView
 )
 
 // Model represents the TUI model
@@ -39,8 +55,14 @@ type Model struct {
 	activeInput  int
 	spinner      spinner.Model
 	results      *call.Result
+	previous     *call.Result // results of the run before, to show deltas
 	callConfig   *call.ConcurrentCall
 	error        string
+	warnings     []string     // problems importing the pasted curl command
+	imported     *call.Config // settings of the pasted curl command the form lacks
+	importedHeader map[string][]string // headers of the pasted curl command, cookie included
+	importedText   string              // headers field as the pasted curl command filled it
+	notice       string       // outcome of copying the curl command
 	startTime    time.Time
 	endTime      time.Time
 	currentProgress int
@@ -49,6 +71,10 @@ type Model struct {
 	width          int
 	height         int
 	animationFrame  int
+	watcher   *call.Watcher  // re-runs config cases, in WatchView
+	watchRun  *call.WatchRun // latest run of the watcher
+	watchStop chan struct{}
+	watching  bool // whether the watcher is running cases
 }
 
 // NewModel creates a new TUI model
@@ -105,8 +131,23 @@ func NewModel() Model {
 	}
 }
 
+// NewWatchModel creates a TUI model showing the runs of a watcher, each
+// case along with its deltas with the previous run
+func NewWatchModel(watcher *call.Watcher) Model {
+	m := NewModel()
+	m.state = WatchView
+	m.watcher = watcher
+	m.watchStop = make(chan struct{})
+	m.watching = true
+	m.statusMessage = "Running cases..."
+	return m
+}
+
 // Init implements tea.Model
 func (m Model) Init() tea.Cmd {
+	if m.watcher != nil {
+		return tea.Batch(m.spinner.Tick, m.runWatch())
+	}
 	return textinput.Blink
 }
 
@@ -131,14 +172,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if msg.String() == "ctrl+c" || msg.String() == "q" {
 				return m, tea.Quit
 			}
+		case WatchView:
+			if msg.String() == "ctrl+c" || msg.String() == "q" {
+				close(m.watchStop)
+				return m, tea.Quit
+			}
 		case ResultsView:
 			switch msg.String() {
 			case "ctrl+c", "q":
 				return m, tea.Quit
+			case "c":
+				return m.copyCurl(), nil
 			case "r", "enter":
-				// Reset to input view
+				// Reset to input view, keeping the results to compare
 				m.state = InputView
 				m.error = ""
+				m.notice = ""
+				m.previous = m.results
 				m.results = nil
 				m.urlInput.Focus()
 				return m, textinput.Blink
@@ -170,8 +220,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.results = msg.results
 		m.currentProgress = m.totalProgress  // Set to 100%
 		m.statusMessage = "Calls completed!"
+		if msg.historyErr != nil {
+			m.error = fmt.Sprintf("Run not saved to the history: %v", msg.historyErr)
+		}
 		return m, nil
 
+	case watchRunMsg:
+		m.watchRun = &msg.run
+		m.watching = false
+		m.statusMessage = "Watching for changes..."
+		return m, m.waitWatch()
+
+	case watchChangeMsg:
+		m.watching = true
+		m.statusMessage = "Running changed cases..."
+		return m, m.runWatch()
+
 	case callErrorMsg:
 		m.state = InputView
 		m.error = msg.error
@@ -210,6 +274,8 @@ func (m Model) updateInputView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "ctrl+c":
 		return m, tea.Quit
+	case "ctrl+y":
+		return m.copyCurl(), nil
 	case "ctrl+p":
 		// Switch to curl paste mode
 		m.state = CurlInputView
@@ -281,7 +347,12 @@ func (m Model) updateInputView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Pass all other key messages to the active input (not method selector)
 		switch m.activeInput {
 		case 0:
+			previousURL := m.urlInput.Value()
 			m.urlInput, cmd = m.urlInput.Update(msg)
+			if m.urlInput.Value() != previousURL {
+				// auth and connection settings belong to the pasted URL
+				m.imported = nil
+			}
 		case 1:
 			m.attemptsInput, cmd = m.attemptsInput.Update(msg)
 		case 2:
@@ -331,19 +402,14 @@ func (m Model) parseCurlAndContinue() (Model, tea.Cmd) {
 		return m, nil
 	}
 
-	// Process the curl command
-
-	// Validate and parse curl command
-	if err := call.ValidateCurlCommand(curlCommand); err != nil {
-		m.error = fmt.Sprintf("Validation failed: %v", err)
-		return m, nil
-	}
-
-	config, err := call.ParseCurlCommand(curlCommand)
+	// Parse curl command, keeping what cannot be honored to show it
+	config, warnings, err := call.ImportCurl(curlCommand)
 	if err != nil {
 		m.error = fmt.Sprintf("Parsing failed: %v", err)
 		return m, nil
 	}
+	m.warnings = warnings
+	m.imported = config
 
 	// Populate form fields from parsed config
 	m.urlInput.SetValue(config.URL)
@@ -356,15 +422,25 @@ func (m Model) parseCurlAndContinue() (Model, tea.Cmd) {
 		}
 	}
 
-	// Set headers
-	if config.Header != nil {
+	// Set headers, keeping cookies visible in the form. Values may hold
+	// commas, so they are sent as imported unless the field is edited
+	m.importedHeader, m.importedText = nil, ""
+	if config.Header != nil || config.Cookie != "" {
+		m.importedHeader = make(map[string][]string)
 		var headerPairs []string
 		for key, values := range config.Header {
+			m.importedHeader[key] = append([]string{}, values...)
 			for _, value := range values {
 				headerPairs = append(headerPairs, fmt.Sprintf("%s:%s", key, value))
 			}
 		}
+		if config.Cookie != "" {
+			m.importedHeader["Cookie"] = []string{config.Cookie}
+			headerPairs = append(headerPairs, "Cookie:"+config.Cookie)
+		}
+		// the field may cut long values, so compare against what it holds
 		m.headersInput.SetValue(strings.Join(headerPairs, ","))
+		m.importedText = m.headersInput.Value()
 	}
 
 	// Set body
@@ -380,16 +456,13 @@ func (m Model) parseCurlAndContinue() (Model, tea.Cmd) {
 	return m, textinput.Blink
 }
 
-// startCall validates inputs and starts the HTTP calls
-func (m Model) startCall() (Model, tea.Cmd) {
-	// Clear previous error
-	m.error = ""
-	
+// formConfig builds the Config of the form, with the settings of the
+// pasted curl command the form lacks
+func (m Model) formConfig() (call.Config, error) {
 	// Validate URL
 	urlString := strings.TrimSpace(m.urlInput.Value())
 	if urlString == "" {
-		m.error = "URL is required"
-		return m, nil
+		return call.Config{}, errors.New("URL is required")
 	}
 	
 	// Parse attempts
@@ -399,8 +472,7 @@ func (m Model) startCall() (Model, tea.Cmd) {
 	}
 	attempts, err := strconv.Atoi(attemptsStr)
 	if err != nil || attempts <= 0 {
-		m.error = "Attempts must be a positive number"
-		return m, nil
+		return call.Config{}, errors.New("Attempts must be a positive number")
 	}
 	
 	// Parse concurrent calls
@@ -410,14 +482,17 @@ func (m Model) startCall() (Model, tea.Cmd) {
 	}
 	concurrent, err := strconv.Atoi(concurrentStr)
 	if err != nil || concurrent <= 0 {
-		m.error = "Concurrent calls must be a positive number"
-		return m, nil
+		return call.Config{}, errors.New("Concurrent calls must be a positive number")
 	}
 	
 	// Parse headers
 	headers := make(map[string][]string)
 	headersStr := strings.TrimSpace(m.headersInput.Value())
-	if headersStr != "" {
+	if m.importedHeader != nil && m.headersInput.Value() == m.importedText {
+		for key, values := range m.importedHeader {
+			headers[key] = append([]string{}, values...)
+		}
+	} else if headersStr != "" {
 		headerPairs := strings.Split(headersStr, ",")
 		for _, pair := range headerPairs {
 			parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
@@ -438,15 +513,42 @@ func (m Model) startCall() (Model, tea.Cmd) {
 	}
 	
 	// Build call configuration with HTTP method, headers, and body
+	// the case is named after the request, keeping each apart in the history
+	method := m.httpMethods[m.selectedMethod]
 	config := call.Config{
-		Name:               "TUI Request",
-		Method:             m.httpMethods[m.selectedMethod],
+		Name:               method + " " + urlString,
+		Method:             method,
 		URL:                urlString,
 		Attempts:           attempts,
 		ConcurrentAttempts: concurrent,
 		Header:             headers,
 		Body:               body,
 	}
+	if m.imported != nil {
+		// auth and connection settings of the pasted curl command
+		config.Auth = m.imported.Auth
+		config.Insecure = m.imported.Insecure
+		config.Proxy = m.imported.Proxy
+		config.Resolve = m.imported.Resolve
+		config.HTTPVersion = m.imported.HTTPVersion
+	}
+	return config, nil
+}
+
+// startCall validates inputs and starts the HTTP calls
+func (m Model) startCall() (Model, tea.Cmd) {
+	// Clear previous error
+	m.error = ""
+	m.notice = ""
+	
+	config, err := m.formConfig()
+	if err != nil {
+		m.error = err.Error()
+		return m, nil
+	}
+	urlString := config.URL
+	attempts := config.Attempts
+	concurrent := config.ConcurrentAttempts
 	
 	// Validate the config
 	if err := config.CheckDefaults(); err != nil {
@@ -476,6 +578,27 @@ func (m Model) startCall() (Model, tea.Cmd) {
 	}
 }
 
+// copyCurl copies the curl command of the form to the clipboard
+func (m Model) copyCurl() Model {
+	m.error = ""
+	m.notice = ""
+	config, err := m.formConfig()
+	if err != nil {
+		m.error = err.Error()
+		return m
+	}
+	command, warnings := call.ExportCurl(config)
+	if err := writeClipboard(command); err != nil {
+		m.error = fmt.Sprintf("Copying failed: %v", err)
+		return m
+	}
+	m.notice = "curl command copied to the clipboard"
+	if len(warnings) > 0 {
+		m.notice += " (" + strings.Join(warnings, "; ") + ")"
+	}
+	return m
+}
+
 // Message types for async operations
 type callStartMsg struct {
 	total int
@@ -486,7 +609,8 @@ type callProgressMsg struct {
 }
 
 type callCompleteMsg struct {
-	results *call.Result
+	results    *call.Result
+	historyErr error // the results could not be kept in the run history
 }
 
 type callErrorMsg struct {
@@ -505,7 +629,7 @@ func (m Model) startCalls() tea.Cmd {
 func (m Model) runCalls() tea.Cmd {
 	return func() tea.Msg {
 		results := m.callConfig.MakeIt()
-		return callCompleteMsg{results: &results}
+		return callCompleteMsg{results: &results, historyErr: saveRun(m.callConfig.GetConfig(), results)}
 	}
 }
 
@@ -519,6 +643,31 @@ func (m Model) simulateProgress() tea.Cmd {
 // progressTickMsg triggers a progress update
 type progressTickMsg struct{}
 
+// watchRunMsg carries a run of the watcher
+type watchRunMsg struct {
+	run call.WatchRun
+}
+
+// watchChangeMsg tells watched files changed
+type watchChangeMsg struct{}
+
+// runWatch runs the cases affected by the last changes
+func (m Model) runWatch() tea.Cmd {
+	return func() tea.Msg {
+		return watchRunMsg{run: m.watcher.Run()}
+	}
+}
+
+// waitWatch waits for watched files to change
+func (m Model) waitWatch() tea.Cmd {
+	return func() tea.Msg {
+		if !m.watcher.Wait(m.watchStop) {
+			return nil
+		}
+		return watchChangeMsg{}
+	}
+}
+
 
 // View implements tea.Model
 func (m Model) View() string {
@@ -531,6 +680,8 @@ func (m Model) View() string {
 		return m.renderLoadingView()
 	case ResultsView:
 		return m.renderResultsView()
+	case WatchView:
+		return m.renderWatchView()
 	default:
 		return "Unknown view state"
 	}
@@ -631,9 +782,24 @@ func (m Model) renderInputView() string {
 		b.WriteString(StatusMessage(m.error, "error"))
 		b.WriteString("\n\n")
 	}
+
+	// Outcome of copying the curl command
+	if m.notice != "" {
+		b.WriteString(StatusMessage(m.notice, "success"))
+		b.WriteString("\n\n")
+	}
+
+	// Warnings of the pasted curl command
+	for _, warning := range m.warnings {
+		b.WriteString(StatusMessage(warning, "warning"))
+		b.WriteString("\n")
+	}
+	if len(m.warnings) > 0 {
+		b.WriteString("\n")
+	}
 	
 	// Instructions
-	instructions := "Press Tab to navigate • Left/Right for method • Enter to start • Ctrl+P to paste curl • Ctrl+C to quit"
+	instructions := "Press Tab to navigate • Left/Right for method • Enter to start • Ctrl+P to paste curl • Ctrl+Y to copy as curl • Ctrl+C to quit"
 	b.WriteString(helpStyle.Render(instructions))
 	
 	return baseStyle.Render(b.String())
@@ -710,18 +876,79 @@ func (m Model) renderResultsView() string {
 	// Results table
 	b.WriteString(cardStyle.Render(m.formatResults()))
 	b.WriteString("\n\n")
+
+	if m.error != "" {
+		b.WriteString(StatusMessage(m.error, "error"))
+		b.WriteString("\n\n")
+	}
+	if m.notice != "" {
+		b.WriteString(StatusMessage(m.notice, "success"))
+		b.WriteString("\n\n")
+	}
 	
-	b.WriteString(helpStyle.Render("Press r or Enter to run again • Ctrl+C or q to quit"))
+	b.WriteString(helpStyle.Render("Press r or Enter to run again • c to copy as curl • Ctrl+C or q to quit"))
 	
 	return baseStyle.Render(b.String())
 }
 
-// formatResults formats the results into a readable table
+// renderWatchView renders the runs of a watcher
+func (m Model) renderWatchView() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("👀 Watching Config"))
+	b.WriteString("\n\n")
+
+	status := m.statusMessage
+	if m.watching {
+		status = m.spinner.View() + " " + status
+	}
+	b.WriteString(status)
+	b.WriteString("\n\n")
+
+	if run := m.watchRun; run != nil {
+		if changed := run.GetChanged(); len(changed) > 0 {
+			b.WriteString(helpStyle.Render("Changed: " + strings.Join(changed, ", ")))
+			b.WriteString("\n\n")
+		}
+		if err := run.GetErr(); err != nil {
+			b.WriteString(StatusMessage(call.MaskSecrets(err.Error()), "error"))
+			b.WriteString("\n\n")
+		} else if len(run.GetResults()) == 0 {
+			b.WriteString(helpStyle.Render("No case changed"))
+			b.WriteString("\n\n")
+		}
+		previous := run.GetPrevious()
+		for i, result := range run.GetResults() {
+			if name := result.GetName(); name != "" {
+				b.WriteString(subtitleStyle.Render("Case: " + call.MaskSecrets(name)))
+				b.WriteString("\n")
+			}
+			b.WriteString(cardStyle.Render(formatResult(&run.GetResults()[i], previous[i])))
+			b.WriteString("\n\n")
+		}
+	}
+
+	b.WriteString(helpStyle.Render("Save the config to run the changed cases again • Ctrl+C or q to quit"))
+
+	return baseStyle.Render(b.String())
+}
+
+// formatResults formats the results into a readable table, along with
+// the deltas with the previous run of the same request
 func (m Model) formatResults() string {
 	if m.results == nil {
 		return "No results to display"
 	}
-	
+	previous := m.previous
+	if previous != nil && (previous.URL == nil || m.results.URL == nil || previous.URL.String() != m.results.URL.String()) {
+		previous = nil
+	}
+	return formatResult(m.results, previous)
+}
+
+// formatResult formats a result into a readable table, followed by its
+// deltas with a previous run unless previous is nil
+func formatResult(result *call.Result, previous *call.Result) string {
 	var b strings.Builder
 	
 	// Stats header
@@ -730,10 +957,13 @@ func (m Model) formatResults() string {
 	b.WriteString(strings.Repeat("─", 50))
 	b.WriteString("\n")
 	
-	b.WriteString(fmt.Sprintf("Total Execution Time: %.2fs\n", m.results.GetTotalExecution()))
-	b.WriteString(fmt.Sprintf("Average Execution Time: %.2fs\n", m.results.GetAvgExecution()))
-	b.WriteString(fmt.Sprintf("Min Execution Time: %.2fs\n", m.results.GetMinExecution()))
-	b.WriteString(fmt.Sprintf("Max Execution Time: %.2fs\n", m.results.GetMaxExecution()))
+	if environment := result.GetEnvironment(); environment != "" {
+		b.WriteString(fmt.Sprintf("Environment: %s\n", environment))
+	}
+	b.WriteString(fmt.Sprintf("Total Execution Time: %.2fs\n", result.GetTotalExecution()))
+	b.WriteString(fmt.Sprintf("Average Execution Time: %.2fs\n", result.GetAvgExecution()))
+	b.WriteString(fmt.Sprintf("Min Execution Time: %.2fs\n", result.GetMinExecution()))
+	b.WriteString(fmt.Sprintf("Max Execution Time: %.2fs\n", result.GetMaxExecution()))
 	b.WriteString("\n")
 	
 	// Status codes table
@@ -746,7 +976,7 @@ func (m Model) formatResults() string {
 	b.WriteString(strings.Repeat("─", 50))
 	b.WriteString("\n")
 	
-	statusMap := m.results.GetStatus()
+	statusMap := result.GetStatus()
 	if len(statusMap) == 0 {
 		return "No status codes to display"
 	}
@@ -772,6 +1002,59 @@ func (m Model) formatResults() string {
 		b.WriteString("\n")
 	}
 	
+	// Checks table
+	checks := result.GetChecks()
+	if len(checks) > 0 {
+		b.WriteString("\n")
+		b.WriteString(tableHeaderStyle.Render("Check"))
+		b.WriteString("\n")
+		b.WriteString(strings.Repeat("─", 50))
+		b.WriteString("\n")
+		for _, check := range checks {
+			checkStyle := successStyle
+			if check.GetFailed() > 0 {
+				checkStyle = errorStyle
+			}
+			b.WriteString(checkStyle.Render(fmt.Sprintf("%6.2f%%", check.GetRatio()*100)))
+			b.WriteString("  ")
+			b.WriteString(tableCellStyle.Render(fmt.Sprintf("%s (%d/%d)", call.MaskSecrets(check.GetName()), check.GetPassed(), check.GetPassed()+check.GetFailed())))
+			b.WriteString("\n")
+		}
+	}
+
+	if previous != nil {
+		b.WriteString(formatDeltas(call.CompareResults(*previous, *result)))
+	}
+	
+	return b.String()
+}
+
+// formatDeltas formats the change of each metric since the previous
+// run, colored by whether it got better or worse
+func formatDeltas(deltas []call.Delta) string {
+	var b strings.Builder
+
+	b.WriteString("\n")
+	b.WriteString(tableHeaderStyle.Render("Since Previous Run"))
+	b.WriteString("\n")
+	b.WriteString(strings.Repeat("─", 50))
+	b.WriteString("\n")
+	for _, delta := range deltas {
+		change := delta.GetChange()
+		if delta.GetMetric() != call.MetricSuccess {
+			change = -change // shorter times are better
+		}
+		changeStyle := tableCellStyle
+		if change > 0 {
+			changeStyle = successStyle
+		} else if change < 0 {
+			changeStyle = errorStyle
+		}
+		b.WriteString(tableCellStyle.Render(fmt.Sprintf("%-8s %8s → %-8s ", delta.GetMetric(), delta.Format(delta.GetPrevious()), delta.Format(delta.GetCurrent()))))
+		b.WriteString(changeStyle.Render(delta.FormatChange()))
+		b.WriteString("\n")
+	}
+
 	return b.String()
 }
 
@@ -1,10 +1,18 @@
 package tui
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/pedrolopesme/call-it/internal/call"
 )
@@ -854,4 +862,221 @@ func TestCallCompletionSetsProgress(t *testing.T) {
 	if cmd != nil {
 		t.Error("Expected no command after completion")
 	}
-}
\ No newline at end of file
+}
+
+// runRequest makes a real call to url and returns its results
+func runRequest(t *testing.T, rawURL string) *call.Result {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callConfig := call.ConcurrentCall{URL: parsedURL, Attempts: 2, ConcurrentAttempts: 1}
+	callConfig.SetConfig(call.Config{Method: "GET", URL: rawURL, Attempts: 2, ConcurrentAttempts: 1})
+	result := callConfig.MakeIt()
+	return &result
+}
+
+func TestFormatResultsWithPreviousRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	model := NewModel()
+	model.state = ResultsView
+	model.results = runRequest(t, server.URL+"/a")
+	if strings.Contains(model.formatResults(), "Since Previous Run") {
+		t.Error("Deltas should not be displayed on a first run")
+	}
+
+	newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	model = newModel.(Model)
+	if model.previous == nil {
+		t.Fatal("Results should be kept when running again")
+	}
+	model.results = runRequest(t, server.URL+"/a")
+	result := model.formatResults()
+	for _, metric := range []string{"Since Previous Run", call.MetricAvg, call.MetricSuccess, "100.00% → 100.00%"} {
+		if !strings.Contains(result, metric) {
+			t.Errorf("Expected deltas to contain %q", metric)
+		}
+	}
+
+	model.results = runRequest(t, server.URL+"/b")
+	if strings.Contains(model.formatResults(), "Since Previous Run") {
+		t.Error("Deltas should not compare runs of different URLs")
+	}
+}
+
+func TestWatchModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`[{"name": "home", "method": "GET", "url": "`+server.URL+`", "attempts": 1}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	watcher, err := call.NewWatcher(path, call.LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model := NewWatchModel(watcher)
+	if model.state != WatchView || model.Init() == nil {
+		t.Fatal("Expected a watch model to start running cases")
+	}
+	newModel, cmd := model.Update(watchRunMsg{run: watcher.Run()})
+	model = newModel.(Model)
+	if model.watching || cmd == nil {
+		t.Error("Expected the model to wait for changes after a run")
+	}
+	view := model.renderWatchView()
+	for _, text := range []string{"Watching for changes", "Case: home", "Execution Stats"} {
+		if !strings.Contains(view, text) {
+			t.Errorf("Expected watch view to contain %q", text)
+		}
+	}
+
+	newModel, _ = model.Update(watchChangeMsg{})
+	model = newModel.(Model)
+	if !model.watching {
+		t.Error("Expected the model to run cases after a change")
+	}
+
+	newModel, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if cmd == nil {
+		t.Error("Expected q to quit the watch view")
+	}
+	select {
+	case <-newModel.(Model).watchStop:
+	default:
+		t.Error("Expected quitting to stop the watcher")
+	}
+}
+
+func TestParseCurlShowsWarnings(t *testing.T) {
+	model := NewModel()
+	model.state = CurlInputView
+	model.curlInput.SetValue(`curl -u ana:s3cret -k --max-time 5 -H 'sec-ch-ua: "Chromium";v="138"' https://httpbin.org/get`)
+
+	model, _ = model.parseCurlAndContinue()
+	if model.state != InputView || model.error != "" {
+		t.Fatalf("Expected the curl command to be parsed, got error %q", model.error)
+	}
+	if !strings.Contains(model.headersInput.Value(), `Sec-Ch-Ua:"Chromium";v="138"`) {
+		t.Errorf("Expected sec-ch-ua header to be kept, got %q", model.headersInput.Value())
+	}
+	if !strings.Contains(model.renderInputView(), "unsupported flag --max-time ignored") {
+		t.Error("Expected warnings to be displayed in input view")
+	}
+
+	model, _ = model.startCall()
+	if model.callConfig == nil {
+		t.Fatalf("Expected the call to start, got error %q", model.error)
+	}
+}
+
+func TestParseBrowserCurlKeepsHeaders(t *testing.T) {
+	model := NewModel()
+	model.state = CurlInputView
+	model.curlInput.SetValue(`curl 'https://httpbin.org/get' -H 'accept: text/html,application/json' -H 'priority: u=0, i' -H 'sec-ch-ua: "Chromium";v="138", "Not)A;Brand";v="8"' -b 'sid=1; theme=dark' --proxy http://proxy:3128`)
+
+	model, _ = model.parseCurlAndContinue()
+	config, err := model.formConfig()
+	if err != nil {
+		t.Fatalf("Expected the form to be valid, got %v", err)
+	}
+	want := map[string][]string{
+		"Accept":    {"text/html,application/json"},
+		"Priority":  {"u=0, i"},
+		"Sec-Ch-Ua": {`"Chromium";v="138", "Not)A;Brand";v="8"`},
+		"Cookie":    {"sid=1; theme=dark"},
+	}
+	if !reflect.DeepEqual(config.Header, want) {
+		t.Errorf("Expected headers %v, got %v", want, config.Header)
+	}
+	if config.Proxy != "http://proxy:3128" {
+		t.Errorf("Expected the proxy to be imported, got %q", config.Proxy)
+	}
+
+	model.headersInput.SetValue("Accept:application/json")
+	config, _ = model.formConfig()
+	if !reflect.DeepEqual(config.Header, map[string][]string{"Accept": {"application/json"}}) {
+		t.Errorf("Expected edited headers to be parsed, got %v", config.Header)
+	}
+
+	model.activeInput = 0
+	newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	model = newModel.(Model)
+	config, _ = model.formConfig()
+	if config.Proxy != "" {
+		t.Errorf("Expected editing the URL to drop the imported proxy, got %q", config.Proxy)
+	}
+}
+
+func TestCopyCurl(t *testing.T) {
+	var copied string
+	writeClipboard = func(text string) error {
+		copied = text
+		return nil
+	}
+	defer func() { writeClipboard = clipboard.WriteAll }()
+
+	model := NewModel()
+	newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+	model = newModel.(Model)
+	if model.error != "URL is required" || copied != "" {
+		t.Errorf("Expected an error without URL, got %q", model.error)
+	}
+
+	model.curlInput.SetValue(`curl -u ana:s3cret -k https://httpbin.org/get`)
+	model, _ = model.parseCurlAndContinue()
+	model.headersInput.SetValue("Accept:application/json")
+	newModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+	model = newModel.(Model)
+	want := "curl https://httpbin.org/get \\\n  -H 'Accept: application/json' \\\n  -u ana:s3cret \\\n  -k"
+	if copied != want {
+		t.Errorf("Expected %q to be copied, got %q", want, copied)
+	}
+	if !strings.Contains(model.renderInputView(), "curl command copied to the clipboard") {
+		t.Error("Expected the copy to be reported in input view")
+	}
+
+	model.state = ResultsView
+	model.results = &call.Result{}
+	copied = ""
+	newModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	if copied != want || newModel.(Model).state != ResultsView {
+		t.Errorf("Expected c to copy the command in results view, got %q", copied)
+	}
+}
+
+func TestRunCallsSavesHistory(t *testing.T) {
+	t.Setenv(call.HistoryDirEnv, t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	model := NewModel()
+	model.urlInput.SetValue(server.URL)
+	model.attemptsInput.SetValue("2")
+	model.concurrentInput.SetValue("1")
+	model, _ = model.startCall()
+	if model.callConfig == nil {
+		t.Fatalf("Expected the call to start, got error %q", model.error)
+	}
+	msg := model.runCalls()().(callCompleteMsg)
+	if msg.historyErr != nil {
+		t.Fatalf("Expected the run to be saved, got %v", msg.historyErr)
+	}
+	history, err := call.OpenHistory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs, _, err := history.List("GET " + server.URL)
+	if err != nil || len(runs) != 1 || runs[0].Result.Requests != 2 {
+		t.Errorf("Expected a run of 2 requests in the history, got %v (%v)", runs, err)
+	}
+
+	newModel, _ := model.Update(callCompleteMsg{results: msg.results, historyErr: errors.New("disk full")})
+	if !strings.Contains(newModel.(Model).renderResultsView(), "Run not saved to the history: disk full") {
+		t.Error("Expected the history failure to be displayed in results view")
+	}
+}
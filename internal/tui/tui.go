@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pedrolopesme/call-it/internal/call"
 )
 
 // Run starts the TUI application
@@ -24,4 +25,13 @@ func Run() error {
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// RunWatch starts the TUI showing the runs of a watcher until quit
+func RunWatch(watcher *call.Watcher) error {
+	p := tea.NewProgram(NewWatchModel(watcher), tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("error running TUI: %w", err)
+	}
+	return nil
+}